@@ -0,0 +1,175 @@
+package networkd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mdlayher/networkd/config"
+)
+
+// ApplyOptions configures Client.Apply.
+type ApplyOptions struct {
+	// Dir is the directory to which configuration files are written. If
+	// empty, /etc/systemd/network is used. Use /run/systemd/network for
+	// configuration which should not persist across reboots.
+	Dir string
+}
+
+// An ApplyResult is the result of reconfiguring a link affected by
+// Client.Apply.
+type ApplyResult struct {
+	Index int
+	Name  string
+
+	// NetworkFile is the path to the .network file which configures the link
+	// after networkd reloaded its configuration, or empty if the link is no
+	// longer configured by any .network file.
+	NetworkFile string
+
+	// Err is non-nil if the link could not be reconfigured.
+	Err error
+}
+
+// Apply writes each configuration file in units, keyed by a file name such as
+// "10-eth0.network", reloads networkd's configuration, and reconfigures each
+// link which was configured by one of the written .network files either before
+// or after the reload. If opts is nil, default options are used.
+//
+// The results are sorted by link index, and a failure to reconfigure one link
+// does not prevent the others from being reconfigured. Virtual devices from
+// .netdev files are created by the reload. .link files are applied by udev and
+// only take effect when udev next processes a link.
+func (c *Client) Apply(ctx context.Context, units map[string]config.Unit, opts *ApplyOptions) ([]ApplyResult, error) {
+	if opts == nil {
+		opts = &ApplyOptions{}
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = "/etc/systemd/network"
+	}
+
+	names := make([]string, 0, len(units))
+	for name, u := range units {
+		if err := checkUnitName(name, u); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	// Note the links configured by the files before they are replaced, since
+	// changes to the files may cause them to no longer match.
+	files, err := c.networkFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	before := make(map[int]string, len(files))
+	for _, l := range files {
+		before[l.Index] = l.NetworkFile
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create configuration directory: %w", err)
+	}
+	for _, name := range names {
+		if err := config.WriteUnit(filepath.Join(dir, name), units[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Manager.Reload(ctx); err != nil {
+		return nil, fmt.Errorf("reload configuration: %w", err)
+	}
+
+	after, err := c.networkFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the file name matters, as a file in a higher priority directory
+	// masks the written file.
+	written := func(file string) bool {
+		_, ok := units[filepath.Base(file)]
+		return ok && strings.HasSuffix(file, ".network")
+	}
+
+	var results []ApplyResult
+	for _, l := range after {
+		if !written(l.NetworkFile) && !written(before[l.Index]) {
+			continue
+		}
+
+		results = append(results, ApplyResult{
+			Index:       l.Index,
+			Name:        l.Name,
+			NetworkFile: l.NetworkFile,
+			Err:         c.Manager.ReconfigureLink(ctx, l.Index),
+		})
+	}
+
+	return results, nil
+}
+
+// checkUnitName verifies that name is a file name with the suffix for u, which
+// must be a known configuration type.
+func checkUnitName(name string, u config.Unit) error {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("networkd: invalid configuration file name %q", name)
+	}
+
+	var ext string
+	switch u.(type) {
+	case *config.Network:
+		ext = ".network"
+	case *config.NetDev:
+		ext = ".netdev"
+	case *config.Link:
+		ext = ".link"
+	default:
+		return fmt.Errorf("networkd: unsupported configuration type %T for file %q", u, name)
+	}
+
+	if !strings.HasSuffix(name, ext) {
+		return fmt.Errorf("networkd: configuration file %q must have suffix %q", name, ext)
+	}
+
+	return nil
+}
+
+// A linkNetworkFile is a link and the .network file which configures it.
+type linkNetworkFile struct {
+	Index       int
+	Name        string
+	NetworkFile string
+}
+
+// networkFiles returns the .network file for each link, sorted by index.
+func (c *Client) networkFiles(ctx context.Context) ([]linkNetworkFile, error) {
+	b, err := c.Manager.DescribeRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jd, err := decodeNetworkDescription(b)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]linkNetworkFile, 0, len(jd.Interfaces))
+	for _, l := range jd.Interfaces {
+		files = append(files, linkNetworkFile{
+			Index:       l.Index,
+			Name:        l.Name,
+			NetworkFile: l.NetworkFile,
+		})
+	}
+	slices.SortFunc(files, func(a, b linkNetworkFile) int { return a.Index - b.Index })
+
+	return files, nil
+}
@@ -0,0 +1,128 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/networkd/config"
+)
+
+func TestClientApply(t *testing.T) {
+	// eth0 matches the new configuration, and eth1 matched the previous
+	// configuration but no longer does. eth2 is unaffected.
+	const (
+		before = `{"Interfaces":[
+			{"Index":1,"Name":"lo"},
+			{"Index":2,"Name":"eth0"},
+			{"Index":3,"Name":"eth1","NetworkFile":"/etc/systemd/network/10-eth.network"},
+			{"Index":4,"Name":"eth2","NetworkFile":"/etc/systemd/network/20-eth2.network"}
+		]}`
+		after = `{"Interfaces":[
+			{"Index":1,"Name":"lo"},
+			{"Index":3,"Name":"eth1"},
+			{"Index":2,"Name":"eth0","NetworkFile":"/run/systemd/network/10-eth.network"},
+			{"Index":4,"Name":"eth2","NetworkFile":"/etc/systemd/network/20-eth2.network"}
+		]}`
+	)
+
+	var (
+		dir      = t.TempDir()
+		reloaded bool
+		methods  []string
+	)
+
+	c := testManagerClient(t, func(method string, args []any) []any {
+		methods = append(methods, method)
+
+		switch method {
+		case "Describe":
+			if reloaded {
+				return []any{after}
+			}
+			return []any{before}
+		case "Reload":
+			reloaded = true
+			return []any{}
+		case "ReconfigureLink":
+			// Pretend eth1 disappeared before it could be reconfigured.
+			if args[0].(int32) == 3 {
+				return nil
+			}
+			return []any{}
+		default:
+			t.Fatalf("unexpected method: %q", method)
+			return nil
+		}
+	})
+
+	units := map[string]config.Unit{
+		"10-eth.network": &config.Network{
+			Match:   config.Match{Name: []string{"eth0"}},
+			Network: config.NetworkSection{DHCP: "yes"},
+		},
+		"20-br0.netdev": &config.NetDev{
+			NetDev: config.NetDevSection{Name: "br0"},
+			Kind:   &config.Bridge{},
+		},
+	}
+
+	results, err := c.Apply(context.Background(), units, &ApplyOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"Describe", "Reload", "Describe", "ReconfigureLink", "ReconfigureLink"}, methods); diff != "" {
+		t.Fatalf("unexpected methods (-want +got):\n%s", diff)
+	}
+
+	if len(results) != 2 || !errors.Is(results[1].Err, os.ErrNotExist) {
+		t.Fatalf("expected eth1 not exist error, but got: %+v", results)
+	}
+	results[1].Err = nil
+
+	want := []ApplyResult{
+		{Index: 2, Name: "eth0", NetworkFile: "/run/systemd/network/10-eth.network"},
+		{Index: 3, Name: "eth1"},
+	}
+	if diff := cmp.Diff(want, results); diff != "" {
+		t.Fatalf("unexpected results (-want +got):\n%s", diff)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "10-eth.network"))
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if diff := cmp.Diff("[Match]\nName=eth0\n\n[Network]\nDHCP=yes\n", string(b)); diff != "" {
+		t.Fatalf("unexpected file (-want +got):\n%s", diff)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "20-br0.netdev")); err != nil {
+		t.Fatalf("failed to stat netdev: %v", err)
+	}
+}
+
+func TestClientApplyInvalidName(t *testing.T) {
+	c := testManagerClient(t, func(method string, _ []any) []any {
+		t.Fatalf("unexpected method: %q", method)
+		return nil
+	})
+
+	for _, name := range []string{"../10-eth0.network", ".network", "10-eth0.netdev"} {
+		t.Run(name, func(t *testing.T) {
+			units := map[string]config.Unit{name: &config.Network{}}
+			if _, err := c.Apply(context.Background(), units, nil); err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+	// A unit of unknown type cannot be matched to a suffix.
+	units := map[string]config.Unit{"10-eth0.network": nil}
+	if _, err := c.Apply(context.Background(), units, nil); err == nil {
+		t.Fatal("expected an error for unknown type, but none occurred")
+	}
+}
@@ -0,0 +1,36 @@
+package networkd
+
+import (
+	"math"
+	"time"
+)
+
+// A bootClock converts networkd's CLOCK_BOOTTIME timestamps to wall clock
+// time.
+type bootClock struct {
+	// now is the wall clock time at which boot, the duration since the system
+	// booted, was measured.
+	now  time.Time
+	boot time.Duration
+}
+
+// newBootClock produces a bootClock for the current time.
+func newBootClock() (bootClock, error) {
+	boot, err := boottime()
+	if err != nil {
+		return bootClock{}, err
+	}
+
+	return bootClock{now: time.Now(), boot: boot}, nil
+}
+
+// Time converts a CLOCK_BOOTTIME timestamp in microseconds to wall clock time.
+// networkd omits or sets math.MaxUint64 for infinite timestamps, so zero and
+// math.MaxUint64 both produce the zero time.Time.
+func (c bootClock) Time(usec uint64) time.Time {
+	if usec == 0 || usec == math.MaxUint64 {
+		return time.Time{}
+	}
+
+	return c.now.Add(time.Duration(usec)*time.Microsecond - c.boot)
+}
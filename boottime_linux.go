@@ -0,0 +1,20 @@
+//go:build linux
+
+package networkd
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// boottime returns the current value of CLOCK_BOOTTIME.
+func boottime() (time.Duration, error) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_BOOTTIME, &ts); err != nil {
+		return 0, fmt.Errorf("networkd: failed to read CLOCK_BOOTTIME: %w", err)
+	}
+
+	return time.Duration(ts.Nano()), nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package networkd
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// boottime is not supported on this platform.
+func boottime() (time.Duration, error) {
+	return 0, fmt.Errorf("networkd: CLOCK_BOOTTIME not supported on %s: %w",
+		runtime.GOOS, errors.ErrUnsupported)
+}
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/godbus/dbus/v5"
 )
@@ -23,6 +26,25 @@ const (
 
 	// methodGet fetches all of an object's D-Bus properties.
 	methodGetAll = "org.freedesktop.DBus.Properties.GetAll"
+
+	// interfaceProperties is the D-Bus properties interface, which emits the
+	// PropertiesChanged signal.
+	interfaceProperties = "org.freedesktop.DBus.Properties"
+
+	// signalPropertiesChanged is the name of the PropertiesChanged signal.
+	signalPropertiesChanged = interfaceProperties + ".PropertiesChanged"
+
+	// busService and busObject identify the D-Bus message bus itself, which
+	// emits the NameOwnerChanged signal.
+	busService = "org.freedesktop.DBus"
+	busObject  = dbus.ObjectPath("/org/freedesktop/DBus")
+
+	// signalNameOwnerChanged is the name of the NameOwnerChanged signal.
+	signalNameOwnerChanged = busService + ".NameOwnerChanged"
+
+	// systemdService and systemdObject identify the systemd service manager.
+	systemdService = "org.freedesktop.systemd1"
+	systemdObject  = dbus.ObjectPath("/org/freedesktop/systemd1")
 )
 
 // A Client can issue D-Bus requests to systemd-networkd.
@@ -35,6 +57,7 @@ type Client struct {
 	call   callFunc
 	get    getFunc
 	getAll getAllFunc
+	watch  watchFunc
 }
 
 // Dial dials a D-Bus connection to systemd-networkd and returns a Client. If
@@ -53,12 +76,83 @@ func Dial(ctx context.Context) (*Client, error) {
 		call:   makeCall(conn),
 		get:    makeGet(conn),
 		getAll: makeAllGet(conn),
+		watch:  makeWatch(conn),
 	})
 }
 
 // Close closes the underlying D-Bus connection.
 func (c *Client) Close() error { return c.c.Close() }
 
+// Properties fetches all D-Bus properties for the networkd Manager object. It
+// is a shortcut for c.Manager.Properties.
+func (c *Client) Properties(ctx context.Context) (ManagerProperties, error) {
+	return c.Manager.Properties(ctx)
+}
+
+// ListLinks lists all of the network links known to systemd-networkd. It is a
+// shortcut for c.Manager.ListLinks.
+func (c *Client) ListLinks(ctx context.Context) ([]Link, error) {
+	return c.Manager.ListLinks(ctx)
+}
+
+// ListLinksDetailed lists all of the network links known to systemd-networkd
+// with their D-Bus properties. It is a shortcut for c.Manager.ListLinksDetailed.
+func (c *Client) ListLinksDetailed(ctx context.Context) ([]DetailedLink, error) {
+	return c.Manager.ListLinksDetailed(ctx)
+}
+
+// GetLinkByName looks up a network link by its name or alternative name. It is
+// a shortcut for c.Manager.GetLinkByName.
+func (c *Client) GetLinkByName(ctx context.Context, name string) (Link, error) {
+	return c.Manager.GetLinkByName(ctx, name)
+}
+
+// GetLinkByIndex looks up a network link by its interface index. It is a
+// shortcut for c.Manager.GetLinkByIndex.
+func (c *Client) GetLinkByIndex(ctx context.Context, index int) (Link, error) {
+	return c.Manager.GetLinkByIndex(ctx, index)
+}
+
+// Reload reloads networkd's configuration files from disk. It is a shortcut
+// for c.Manager.Reload.
+func (c *Client) Reload(ctx context.Context) error {
+	return c.Manager.Reload(ctx)
+}
+
+// SystemdVersion fetches the major version of the running systemd, such as
+// 255, from the systemd service manager. The version determines which
+// configuration options networkd supports.
+func (c *Client) SystemdVersion(ctx context.Context) (int, error) {
+	var v dbus.Variant
+	err := c.call(ctx, systemdService, methodGet, systemdObject, &v, systemdService+".Manager", "Version")
+	if err != nil {
+		return 0, fmt.Errorf("get systemd version: %w", err)
+	}
+
+	s, ok := v.Value().(string)
+	if !ok {
+		return 0, fmt.Errorf("networkd: unexpected type %T for systemd version", v.Value())
+	}
+
+	return parseSystemdVersion(s)
+}
+
+// parseSystemdVersion parses the major version from a systemd version string
+// such as "255.4-1ubuntu8" or "v256".
+func parseSystemdVersion(s string) (int, error) {
+	v := strings.TrimPrefix(s, "v")
+	if i := strings.IndexFunc(v, func(r rune) bool { return r < '0' || r > '9' }); i != -1 {
+		v = v[:i]
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("networkd: malformed systemd version %q", s)
+	}
+
+	return n, nil
+}
+
 // initClient verifies a Client can speak with systemd-networkd.
 func initClient(ctx context.Context, c *Client) (*Client, error) {
 	// See if the Manager object is available on the system bus.
@@ -79,12 +173,20 @@ type ManagerService struct {
 // ManagerProperties contains all of the D-Bus properties for the networkd
 // Manager object.
 type ManagerProperties struct {
-	OperationalState string
-	CarrierState     string
-	AddressState     string
-	IPv4AddressState string
-	IPv6AddressState string
-	OnlineState      string
+	OperationalState OperationalState
+	CarrierState     CarrierState
+	AddressState     AddressState
+	IPv4AddressState AddressState
+	IPv6AddressState AddressState
+	OnlineState      OnlineState
+
+	// NamespaceID is the inode number of the network namespace in which
+	// networkd is running, or 0 if networkd does not report it.
+	NamespaceID uint64
+
+	// Raw contains all of the unprocessed D-Bus properties, including any
+	// which are not yet exposed by this package.
+	Raw map[string]dbus.Variant
 }
 
 // Properties fetches all D-Bus properties for the networkd Manager object.
@@ -94,14 +196,94 @@ func (ms *ManagerService) Properties(ctx context.Context) (ManagerProperties, er
 		return ManagerProperties{}, err
 	}
 
-	return ManagerProperties{
-		OperationalState: out["OperationalState"].Value().(string),
-		CarrierState:     out["CarrierState"].Value().(string),
-		AddressState:     out["AddressState"].Value().(string),
-		IPv4AddressState: out["IPv4AddressState"].Value().(string),
-		IPv6AddressState: out["IPv6AddressState"].Value().(string),
-		OnlineState:      out["OnlineState"].Value().(string),
-	}, nil
+	return parseManagerProperties(out)
+}
+
+// parseManagerProperties unpacks ManagerProperties from a D-Bus property map.
+// Properties which are missing are left unset.
+func parseManagerProperties(out map[string]dbus.Variant) (ManagerProperties, error) {
+	props := ManagerProperties{Raw: out}
+
+	for _, p := range []struct {
+		name string
+		s    *string
+	}{
+		{"OperationalState", (*string)(&props.OperationalState)},
+		{"CarrierState", (*string)(&props.CarrierState)},
+		{"AddressState", (*string)(&props.AddressState)},
+		{"IPv4AddressState", (*string)(&props.IPv4AddressState)},
+		{"IPv6AddressState", (*string)(&props.IPv6AddressState)},
+		{"OnlineState", (*string)(&props.OnlineState)},
+	} {
+		if err := propertyString(out, p.name, p.s); err != nil {
+			return ManagerProperties{}, err
+		}
+	}
+
+	// NamespaceId is only available in newer versions of networkd.
+	if v, ok := out["NamespaceId"]; ok {
+		props.NamespaceID, _ = v.Value().(uint64)
+	}
+
+	return props, nil
+}
+
+// OperationalState fetches the system's operational state using a single
+// D-Bus property request.
+func (ms *ManagerService) OperationalState(ctx context.Context) (OperationalState, error) {
+	s, err := ms.getString(ctx, "OperationalState")
+	return OperationalState(s), err
+}
+
+// CarrierState fetches the system's carrier state using a single D-Bus
+// property request.
+func (ms *ManagerService) CarrierState(ctx context.Context) (CarrierState, error) {
+	s, err := ms.getString(ctx, "CarrierState")
+	return CarrierState(s), err
+}
+
+// AddressState fetches the system's address state using a single D-Bus
+// property request.
+func (ms *ManagerService) AddressState(ctx context.Context) (AddressState, error) {
+	s, err := ms.getString(ctx, "AddressState")
+	return AddressState(s), err
+}
+
+// IPv4AddressState fetches the system's IPv4 address state using a single
+// D-Bus property request.
+func (ms *ManagerService) IPv4AddressState(ctx context.Context) (AddressState, error) {
+	s, err := ms.getString(ctx, "IPv4AddressState")
+	return AddressState(s), err
+}
+
+// IPv6AddressState fetches the system's IPv6 address state using a single
+// D-Bus property request.
+func (ms *ManagerService) IPv6AddressState(ctx context.Context) (AddressState, error) {
+	s, err := ms.getString(ctx, "IPv6AddressState")
+	return AddressState(s), err
+}
+
+// OnlineState fetches the system's online state using a single D-Bus property
+// request.
+func (ms *ManagerService) OnlineState(ctx context.Context) (OnlineState, error) {
+	s, err := ms.getString(ctx, "OnlineState")
+	return OnlineState(s), err
+}
+
+// getString fetches a single string D-Bus property for the networkd Manager
+// object.
+func (ms *ManagerService) getString(ctx context.Context, prop string) (string, error) {
+	v, err := ms.c.get(ctx, objectPath(), interfacePath("Manager"), prop)
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := v.Value().(string)
+	if !ok {
+		return "", fmt.Errorf("networkd: unexpected type %T for property %q", v.Value(), prop)
+	}
+
+	return s, nil
 }
 
 // A Link is a network link known to systemd-networkd.
@@ -138,6 +320,218 @@ func (ms *ManagerService) ListLinks(ctx context.Context) ([]Link, error) {
 	return links, nil
 }
 
+// A DetailedLink is a Link and its D-Bus properties, as returned by
+// ManagerService.ListLinksDetailed.
+type DetailedLink struct {
+	Link
+	LinkProperties
+}
+
+// ListLinksDetailed lists all of the network links known to systemd-networkd
+// and fetches the D-Bus properties for each link concurrently. Links which
+// disappear before their properties can be fetched are omitted.
+func (ms *ManagerService) ListLinksDetailed(ctx context.Context) ([]DetailedLink, error) {
+	links, err := ms.ListLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bound the number of concurrent requests so that hosts with many links
+	// don't flood the bus.
+	const concurrency = 16
+
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, concurrency)
+		out  = make([]DetailedLink, len(links))
+		ok   = make([]bool, len(links))
+		errs = make([]error, len(links))
+	)
+
+	for i, l := range links {
+		wg.Add(1)
+		go func(i int, l Link) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			props, err := ms.c.Link(l).Properties(ctx)
+			switch {
+			case isUnknownObject(err):
+				// The link disappeared after it was listed.
+			case err != nil:
+				errs[i] = fmt.Errorf("get properties for link %q: %w", l.Name, err)
+			default:
+				out[i] = DetailedLink{Link: l, LinkProperties: props}
+				ok[i] = true
+			}
+		}(i, l)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	detailed := make([]DetailedLink, 0, len(links))
+	for i := range out {
+		if ok[i] {
+			detailed = append(detailed, out[i])
+		}
+	}
+
+	return detailed, nil
+}
+
+// GetLinkByName looks up a network link known to systemd-networkd by its name
+// or by one of its alternative names. If no such link exists, an error
+// compatible with `errors.Is(err, os.ErrNotExist)` is returned.
+func (ms *ManagerService) GetLinkByName(ctx context.Context, name string) (Link, error) {
+	var (
+		index int32
+		op    dbus.ObjectPath
+	)
+
+	err := ms.call(ctx, "GetLinkByName", []any{&index, &op}, name)
+	if err == nil {
+		// networkd may have matched an alternative name, so find the link's
+		// canonical name.
+		return ms.GetLinkByIndex(ctx, int(index))
+	}
+
+	err = toNotExist(err)
+	if !errors.Is(err, os.ErrNotExist) {
+		return Link{}, err
+	}
+
+	// Older versions of networkd do not match alternative names, so check
+	// the description of each link for a match.
+	b, derr := ms.DescribeRaw(ctx)
+	if derr != nil {
+		return Link{}, derr
+	}
+
+	jd, derr := decodeNetworkDescription(b)
+	if derr != nil {
+		return Link{}, derr
+	}
+
+	for _, l := range jd.Interfaces {
+		if !slices.Contains(l.AlternativeNames, name) {
+			continue
+		}
+
+		link, lerr := ms.GetLinkByIndex(ctx, l.Index)
+		if errors.Is(lerr, os.ErrNotExist) {
+			// The link disappeared after it was described.
+			break
+		}
+
+		return link, lerr
+	}
+
+	return Link{}, err
+}
+
+// GetLinkByIndex looks up a network link known to systemd-networkd by its
+// interface index. If no such link exists, an error compatible with
+// `errors.Is(err, os.ErrNotExist)` is returned.
+func (ms *ManagerService) GetLinkByIndex(ctx context.Context, index int) (Link, error) {
+	var (
+		name string
+		op   dbus.ObjectPath
+	)
+
+	if err := ms.call(ctx, "GetLinkByIndex", []any{&name, &op}, int32(index)); err != nil {
+		return Link{}, toNotExist(err)
+	}
+
+	return Link{
+		Index:      index,
+		Name:       name,
+		ObjectPath: op,
+	}, nil
+}
+
+// RenewLink triggers a renewal of the DHCPv4 lease for the link with the
+// specified interface index.
+func (ms *ManagerService) RenewLink(ctx context.Context, index int) error {
+	if err := ms.call(ctx, "RenewLink", nil, int32(index)); err != nil {
+		return fmt.Errorf("renew link %d: %w", index, toNotExist(err))
+	}
+
+	return nil
+}
+
+// ForceRenewLink sends a DHCP FORCERENEW message to all clients of the DHCP
+// server running on the link with the specified interface index, requesting
+// that they renew their leases.
+func (ms *ManagerService) ForceRenewLink(ctx context.Context, index int) error {
+	if err := ms.call(ctx, "ForceRenewLink", nil, int32(index)); err != nil {
+		return fmt.Errorf("force renew link %d: %w", index, toNotExist(err))
+	}
+
+	return nil
+}
+
+// ReconfigureLink reapplies the configuration for the link with the specified
+// interface index. This is typically used after modifying the link's .network
+// file and calling Reload.
+func (ms *ManagerService) ReconfigureLink(ctx context.Context, index int) error {
+	if err := ms.call(ctx, "ReconfigureLink", nil, int32(index)); err != nil {
+		return fmt.Errorf("reconfigure link %d: %w", index, toNotExist(err))
+	}
+
+	return nil
+}
+
+// Reload reloads all .network, .netdev, and .link files from disk and
+// reconfigures any links whose configuration has changed.
+func (ms *ManagerService) Reload(ctx context.Context) error {
+	if err := ms.call(ctx, "Reload", nil); err != nil {
+		return fmt.Errorf("reload networkd: %w", err)
+	}
+
+	return nil
+}
+
+// DescribeRaw returns networkd's JSON description of its global state and of
+// all links it knows about. Use Describe to decode the description.
+func (ms *ManagerService) DescribeRaw(ctx context.Context) ([]byte, error) {
+	var s string
+	if err := ms.call(ctx, "Describe", &s); err != nil {
+		return nil, fmt.Errorf("describe networkd: %w", err)
+	}
+
+	return []byte(s), nil
+}
+
+// SetPersistentStorage notifies networkd whether its persistent storage
+// directory, /var/lib/systemd/network, is ready for use. This is typically
+// used by early-boot agents once the file system containing /var is writable,
+// and before it is unmounted at shutdown.
+//
+// If networkd does not support persistent storage, an error compatible with
+// `errors.Is(err, errors.ErrUnsupported)` is returned.
+func (ms *ManagerService) SetPersistentStorage(ctx context.Context, ready bool) error {
+	err := ms.call(ctx, "SetPersistentStorage", nil, ready)
+	switch {
+	case err == nil:
+		return nil
+	case isUnknownMethod(err):
+		return fmt.Errorf("set persistent storage: %w: %v", errors.ErrUnsupported, err)
+	default:
+		return fmt.Errorf("set persistent storage: %w", err)
+	}
+}
+
+// call calls a D-Bus method on the networkd Manager interface. If out is a
+// []any, each of the method's output values is stored in the corresponding
+// element of out.
+func (ms *ManagerService) call(ctx context.Context, method string, out any, args ...any) error {
+	return ms.c.call(ctx, baseService, interfacePath("Manager", method), objectPath(), out, args...)
+}
+
 // objectPath prepends its arguments with the base object path for networkd.
 func objectPath(ss ...string) dbus.ObjectPath {
 	p := dbus.ObjectPath(path.Join(
@@ -160,19 +554,37 @@ func interfacePath(ss ...string) string {
 	return strings.Join(append([]string{baseService}, ss...), ".")
 }
 
-// toNotExist wraps a D-Bus "no such unit" error with os.ErrNotExist for easy
-// comparison.
+// toNotExist wraps a D-Bus "no such unit" or "no such link" error with
+// os.ErrNotExist for easy comparison.
 func toNotExist(err error) error {
 	var derr dbus.Error
-	if !errors.As(err, &derr) || derr.Name != "org.freedesktop.systemd1.NoSuchUnit" {
+	if !errors.As(err, &derr) {
+		return err
+	}
+
+	switch derr.Name {
+	case "org.freedesktop.systemd1.NoSuchUnit", "org.freedesktop.network1.NoSuchLink":
+	default:
 		return err
 	}
 
 	return fmt.Errorf("%v: %w", err, os.ErrNotExist)
 }
 
+// errConnClosed indicates that the D-Bus connection was closed while waiting
+// for signals.
+var errConnClosed = errors.New("networkd: D-Bus connection closed")
+
+// isUnknownObject reports whether err indicates that a D-Bus object does not
+// exist.
+func isUnknownObject(err error) bool {
+	var derr dbus.Error
+	return errors.As(err, &derr) && derr.Name == "org.freedesktop.DBus.Error.UnknownObject"
+}
+
 // A callFunc is a function which calls a D-Bus method on an object and
-// optionally stores its output in the pointer provided to out.
+// optionally stores its output in the pointer provided to out. If out is a
+// []any of pointers, each of the method's output values is stored in turn.
 type callFunc func(ctx context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error
 
 // A getFunc is a function which fetches a D-Bus property from an object.
@@ -181,6 +593,13 @@ type getFunc func(ctx context.Context, op dbus.ObjectPath, iface, prop string) (
 // A getAllFunc is a function which fetches all D-Bus properties for an object.
 type getAllFunc func(ctx context.Context, op dbus.ObjectPath, iface string) (map[string]dbus.Variant, error)
 
+// A watchFunc is a function which subscribes to D-Bus signals matching opts.
+// Matching signals are delivered on the returned channel until the returned
+// function is called to end the subscription. The channel may also receive
+// signals matched by other subscriptions on the same connection, so callers
+// must filter the signals they receive.
+type watchFunc func(ctx context.Context, opts ...dbus.MatchOption) (<-chan *dbus.Signal, func() error, error)
+
 // makeCall produces a callFunc which calls a D-Bus method on an object.
 func makeCall(c *dbus.Conn) callFunc {
 	return func(ctx context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
@@ -189,12 +608,16 @@ func makeCall(c *dbus.Conn) callFunc {
 			return fmt.Errorf("call %q: %w", method, call.Err)
 		}
 
-		// Store the results of the call only when out is not nil.
-		if out == nil {
+		// Store the results of the call only when out is not nil, spreading
+		// multiple output values across a slice of pointers.
+		switch out := out.(type) {
+		case nil:
 			return nil
+		case []any:
+			return call.Store(out...)
+		default:
+			return call.Store(out)
 		}
-
-		return call.Store(out)
 	}
 }
 
@@ -228,6 +651,76 @@ func makeAllGet(c *dbus.Conn) getAllFunc {
 	}
 }
 
+// makeWatch produces a watchFunc which subscribes to D-Bus signals. The
+// returned cancel function may be called more than once.
+func makeWatch(c *dbus.Conn) watchFunc {
+	return func(ctx context.Context, opts ...dbus.MatchOption) (<-chan *dbus.Signal, func() error, error) {
+		if err := c.AddMatchSignalContext(ctx, opts...); err != nil {
+			return nil, nil, fmt.Errorf("add signal match: %w", err)
+		}
+
+		ch := make(chan *dbus.Signal, 16)
+		c.Signal(ch)
+
+		var (
+			once sync.Once
+			err  error
+		)
+
+		return ch, func() error {
+			once.Do(func() {
+				// Stop delivery before discarding any buffered signals
+				// so that nothing else is sent on ch.
+				c.RemoveSignal(ch)
+				for len(ch) > 0 {
+					<-ch
+				}
+
+				// The match rule is removed even if ctx was canceled, so
+				// that long-lived connections do not accumulate rules.
+				if rerr := c.RemoveMatchSignal(opts...); rerr != nil {
+					err = fmt.Errorf("remove signal match: %w", rerr)
+				}
+			})
+
+			return err
+		}, nil
+	}
+}
+
+// propertiesChanged parses the changed properties for iface from a
+// PropertiesChanged signal emitted by op. If s is not such a signal, ok is
+// false.
+func propertiesChanged(s *dbus.Signal, op dbus.ObjectPath, iface string) (changed map[string]dbus.Variant, ok bool) {
+	if s.Name != signalPropertiesChanged || s.Path != op || len(s.Body) != 3 {
+		return nil, false
+	}
+
+	// The signal body is (s interface, a{sv} changed, as invalidated).
+	if i, ok := s.Body[0].(string); !ok || i != iface {
+		return nil, false
+	}
+
+	changed, ok = s.Body[1].(map[string]dbus.Variant)
+	return changed, ok
+}
+
+// nameOwnerChanged parses the new owner of name from a NameOwnerChanged signal
+// emitted by the bus. If s is not such a signal, ok is false.
+func nameOwnerChanged(s *dbus.Signal, name string) (owner string, ok bool) {
+	if s.Name != signalNameOwnerChanged || s.Path != busObject || len(s.Body) != 3 {
+		return "", false
+	}
+
+	// The signal body is (s name, s old_owner, s new_owner).
+	if n, ok := s.Body[0].(string); !ok || n != name {
+		return "", false
+	}
+
+	owner, ok = s.Body[2].(string)
+	return owner, ok
+}
+
 func panicf(format string, a ...any) {
 	panic(fmt.Sprintf(format, a...))
 }
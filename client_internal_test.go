@@ -0,0 +1,537 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+// testLinks are the links returned by ListLinks for ManagerService unit tests.
+var testLinks = []Link{
+	{Index: 1, Name: "lo", ObjectPath: objectPath("link", "_31")},
+	testLink,
+}
+
+func TestManagerServiceProperties(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra map[string]dbus.Variant
+		nsID  uint64
+	}{
+		{
+			name: "no namespace ID",
+		},
+		{
+			name:  "namespace ID",
+			extra: map[string]dbus.Variant{"NamespaceId": dbus.MakeVariant(uint64(4026531840))},
+			nsID:  4026531840,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := testManagerProperties()
+			for k, v := range tt.extra {
+				props[k] = v
+			}
+
+			c := &Client{
+				getAll: func(_ context.Context, op dbus.ObjectPath, iface string) (map[string]dbus.Variant, error) {
+					if diff := cmp.Diff(objectPath(), op); diff != "" {
+						t.Fatalf("unexpected object path (-want +got):\n%s", diff)
+					}
+					if diff := cmp.Diff(interfacePath("Manager"), iface); diff != "" {
+						t.Fatalf("unexpected interface (-want +got):\n%s", diff)
+					}
+
+					return props, nil
+				},
+			}
+			c.Manager = &ManagerService{c: c}
+
+			got, err := c.Manager.Properties(context.Background())
+			if err != nil {
+				t.Fatalf("failed to get properties: %v", err)
+			}
+
+			want := ManagerProperties{
+				OperationalState: OperationalRoutable,
+				CarrierState:     CarrierCarrier,
+				AddressState:     AddressRoutable,
+				IPv4AddressState: AddressRoutable,
+				IPv6AddressState: AddressDegraded,
+				OnlineState:      OnlineOnline,
+				NamespaceID:      tt.nsID,
+				Raw:              props,
+			}
+
+			if diff := cmp.Diff(want, got, cmp.Comparer(variantEqual)); diff != "" {
+				t.Fatalf("unexpected properties (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestManagerServiceListLinksDetailed(t *testing.T) {
+	c := testManagerClient(t, func(method string, _ []any) []any {
+		if diff := cmp.Diff("ListLinks", method); diff != "" {
+			t.Fatalf("unexpected method (-want +got):\n%s", diff)
+		}
+
+		return []any{testListLinks()}
+	})
+
+	c.getAll = func(_ context.Context, op dbus.ObjectPath, _ string) (map[string]dbus.Variant, error) {
+		// Pretend the loopback link disappeared after it was listed.
+		if op != testLink.ObjectPath {
+			return nil, dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownObject"}
+		}
+
+		return testLinkProperties(), nil
+	}
+
+	links, err := c.Manager.ListLinksDetailed(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list links: %v", err)
+	}
+
+	want := []DetailedLink{{
+		Link: testLink,
+		LinkProperties: LinkProperties{
+			OperationalState:    OperationalRoutable,
+			CarrierState:        CarrierCarrier,
+			AddressState:        AddressRoutable,
+			IPv4AddressState:    AddressRoutable,
+			IPv6AddressState:    AddressDegraded,
+			OnlineState:         OnlineOnline,
+			AdministrativeState: AdministrativeConfigured,
+			TxBitRate:           1000000,
+			RxBitRate:           2000000,
+			Raw:                 testLinkProperties(),
+		},
+	}}
+
+	if diff := cmp.Diff(want, links, cmp.Comparer(variantEqual)); diff != "" {
+		t.Fatalf("unexpected links (-want +got):\n%s", diff)
+	}
+}
+
+func TestManagerServicePropertyGetters(t *testing.T) {
+	c := &Client{
+		get: func(_ context.Context, op dbus.ObjectPath, iface, prop string) (dbus.Variant, error) {
+			if diff := cmp.Diff(objectPath(), op); diff != "" {
+				t.Fatalf("unexpected object path (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(interfacePath("Manager"), iface); diff != "" {
+				t.Fatalf("unexpected interface (-want +got):\n%s", diff)
+			}
+
+			v, ok := testManagerProperties()[prop]
+			if !ok {
+				t.Fatalf("unexpected property: %q", prop)
+			}
+
+			return v, nil
+		},
+	}
+	c.Manager = &ManagerService{c: c}
+
+	var (
+		ctx  = context.Background()
+		got  []string
+		errs []error
+	)
+
+	add := func(s string, err error) {
+		got = append(got, s)
+		errs = append(errs, err)
+	}
+
+	ops, err := c.Manager.OperationalState(ctx)
+	add(string(ops), err)
+	cs, err := c.Manager.CarrierState(ctx)
+	add(string(cs), err)
+	as, err := c.Manager.AddressState(ctx)
+	add(string(as), err)
+	as4, err := c.Manager.IPv4AddressState(ctx)
+	add(string(as4), err)
+	as6, err := c.Manager.IPv6AddressState(ctx)
+	add(string(as6), err)
+	ons, err := c.Manager.OnlineState(ctx)
+	add(string(ons), err)
+
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("failed to get properties: %v", err)
+	}
+
+	want := []string{"routable", "carrier", "routable", "routable", "degraded", "online"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected properties (-want +got):\n%s", diff)
+	}
+}
+
+func TestManagerServiceGetLinkByName(t *testing.T) {
+	tests := []struct {
+		name, lookup string
+		altnames     bool
+		want         Link
+		notExist     bool
+	}{
+		{
+			name:     "name",
+			lookup:   "eth0",
+			altnames: true,
+			want:     testLink,
+		},
+		{
+			name:     "alternative name",
+			lookup:   "enp0s3",
+			altnames: true,
+			want:     testLink,
+		},
+		{
+			name:   "alternative name fallback",
+			lookup: "enp0s3",
+			want:   testLink,
+		},
+		{
+			name:     "not found",
+			lookup:   "eth2",
+			notExist: true,
+		},
+		{
+			name:     "vanished",
+			lookup:   "gone",
+			notExist: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testManagerClient(t, func(method string, args []any) []any {
+				switch method {
+				case "GetLinkByName":
+					// Only match alternative names when networkd supports it.
+					name := args[0].(string)
+					if name == "eth0" || (tt.altnames && name == "enp0s3") {
+						return []any{int32(2), testLink.ObjectPath}
+					}
+
+					return nil
+				case "GetLinkByIndex":
+					return testGetLinkByIndex(args)
+				case "Describe":
+					// Describe is used when networkd does not match
+					// alternative names itself.
+					return []any{`{"Interfaces":[
+						{"Index":1,"Name":"lo"},
+						{"Index":2,"Name":"eth0","AlternativeNames":["enp0s3"]},
+						{"Index":3,"Name":"eth1","AlternativeNames":["gone"]}
+					]}`}
+				default:
+					t.Fatalf("unexpected method: %q", method)
+					return nil
+				}
+			})
+
+			l, err := c.Manager.GetLinkByName(context.Background(), tt.lookup)
+			if tt.notExist {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("expected not exist error, but got: %v", err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get link: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, l); diff != "" {
+				t.Fatalf("unexpected link (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestManagerServiceGetLinkByIndex(t *testing.T) {
+	c := testManagerClient(t, func(method string, args []any) []any {
+		if diff := cmp.Diff("GetLinkByIndex", method); diff != "" {
+			t.Fatalf("unexpected method (-want +got):\n%s", diff)
+		}
+
+		return testGetLinkByIndex(args)
+	})
+
+	l, err := c.Manager.GetLinkByIndex(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("failed to get link: %v", err)
+	}
+
+	if diff := cmp.Diff(testLink, l); diff != "" {
+		t.Fatalf("unexpected link (-want +got):\n%s", diff)
+	}
+
+	if _, err := c.Manager.GetLinkByIndex(context.Background(), 3); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, but got: %v", err)
+	}
+}
+
+func TestManagerServiceLinkMethods(t *testing.T) {
+	tests := []struct {
+		method string
+		fn     func(ms *ManagerService, index int) error
+	}{
+		{
+			method: "RenewLink",
+			fn: func(ms *ManagerService, index int) error {
+				return ms.RenewLink(context.Background(), index)
+			},
+		},
+		{
+			method: "ForceRenewLink",
+			fn: func(ms *ManagerService, index int) error {
+				return ms.ForceRenewLink(context.Background(), index)
+			},
+		},
+		{
+			method: "ReconfigureLink",
+			fn: func(ms *ManagerService, index int) error {
+				return ms.ReconfigureLink(context.Background(), index)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			c := testManagerClient(t, func(method string, args []any) []any {
+				if diff := cmp.Diff(tt.method, method); diff != "" {
+					t.Fatalf("unexpected method (-want +got):\n%s", diff)
+				}
+
+				// Only the test link exists.
+				if args[0].(int32) != int32(testLink.Index) {
+					return nil
+				}
+
+				return []any{}
+			})
+
+			if err := tt.fn(c.Manager, testLink.Index); err != nil {
+				t.Fatalf("failed to call method: %v", err)
+			}
+
+			if err := tt.fn(c.Manager, 3); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("expected not exist error, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestManagerServiceReload(t *testing.T) {
+	var called bool
+	c := testManagerClient(t, func(method string, args []any) []any {
+		if diff := cmp.Diff("Reload", method); diff != "" {
+			t.Fatalf("unexpected method (-want +got):\n%s", diff)
+		}
+
+		called = true
+		return []any{}
+	})
+
+	if err := c.Manager.Reload(context.Background()); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if !called {
+		t.Fatal("Reload was not called")
+	}
+}
+
+func TestManagerServiceDescribeRaw(t *testing.T) {
+	const want = `{"Interfaces":[]}`
+	c := testManagerClient(t, func(method string, args []any) []any {
+		if diff := cmp.Diff("Describe", method); diff != "" {
+			t.Fatalf("unexpected method (-want +got):\n%s", diff)
+		}
+
+		return []any{want}
+	})
+
+	b, err := c.Manager.DescribeRaw(context.Background())
+	if err != nil {
+		t.Fatalf("failed to describe: %v", err)
+	}
+
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Fatalf("unexpected description (-want +got):\n%s", diff)
+	}
+}
+
+func TestManagerServiceSetPersistentStorage(t *testing.T) {
+	c := testManagerClient(t, func(method string, args []any) []any {
+		if diff := cmp.Diff("SetPersistentStorage", method); diff != "" {
+			t.Fatalf("unexpected method (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]any{true}, args); diff != "" {
+			t.Fatalf("unexpected arguments (-want +got):\n%s", diff)
+		}
+
+		return []any{}
+	})
+
+	if err := c.Manager.SetPersistentStorage(context.Background(), true); err != nil {
+		t.Fatalf("failed to set persistent storage: %v", err)
+	}
+
+	// Older versions of networkd do not implement the method.
+	c.call = func(_ context.Context, _, _ string, _ dbus.ObjectPath, _ any, _ ...any) error {
+		return dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod"}
+	}
+
+	if err := c.Manager.SetPersistentStorage(context.Background(), true); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("expected unsupported error, but got: %v", err)
+	}
+}
+
+// testManagerClient produces a Client which responds to Manager method calls
+// using fn. fn returns the method's output values, or nil if the method should
+// return a "no such link" error.
+func testManagerClient(t *testing.T, fn func(method string, args []any) []any) *Client {
+	t.Helper()
+
+	c := &Client{
+		call: func(_ context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
+			if diff := cmp.Diff(baseService, service); diff != "" {
+				t.Fatalf("unexpected service (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(objectPath(), op); diff != "" {
+				t.Fatalf("unexpected object path (-want +got):\n%s", diff)
+			}
+
+			m, ok := strings.CutPrefix(method, interfacePath("Manager")+".")
+			if !ok {
+				t.Fatalf("unexpected method: %q", method)
+			}
+
+			vs := fn(m, args)
+			if vs == nil {
+				return dbus.Error{Name: "org.freedesktop.network1.NoSuchLink"}
+			}
+
+			switch out := out.(type) {
+			case nil:
+				return nil
+			case []any:
+				return dbus.Store(vs, out...)
+			default:
+				return dbus.Store(vs, out)
+			}
+		},
+	}
+
+	c.Manager = &ManagerService{c: c}
+	return c
+}
+
+// testManagerProperties returns the D-Bus properties for a typical routable
+// system.
+func testManagerProperties() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"OperationalState": dbus.MakeVariant("routable"),
+		"CarrierState":     dbus.MakeVariant("carrier"),
+		"AddressState":     dbus.MakeVariant("routable"),
+		"IPv4AddressState": dbus.MakeVariant("routable"),
+		"IPv6AddressState": dbus.MakeVariant("degraded"),
+		"OnlineState":      dbus.MakeVariant("online"),
+	}
+}
+
+// testGetLinkByIndex returns the GetLinkByIndex D-Bus output for the link in
+// testLinks with the index in args, or nil if no such link exists.
+func testGetLinkByIndex(args []any) []any {
+	for _, l := range testLinks {
+		if int32(l.Index) == args[0].(int32) {
+			return []any{l.Name, l.ObjectPath}
+		}
+	}
+
+	return nil
+}
+
+// testListLinks returns the ListLinks D-Bus output for testLinks.
+func testListLinks() [][]any {
+	vs := make([][]any, 0, len(testLinks))
+	for _, l := range testLinks {
+		vs = append(vs, []any{int32(l.Index), l.Name, l.ObjectPath})
+	}
+
+	return vs
+}
+
+func TestClientSystemdVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		v       int
+		ok      bool
+	}{
+		{
+			name:    "malformed",
+			version: "unknown",
+		},
+		{
+			name:    "distribution",
+			version: "255.4-1ubuntu8",
+			v:       255,
+			ok:      true,
+		},
+		{
+			name:    "release candidate",
+			version: "v256~rc3",
+			v:       256,
+			ok:      true,
+		},
+		{
+			name:    "plain",
+			version: "249",
+			v:       249,
+			ok:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				call: func(_ context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
+					if diff := cmp.Diff(systemdService, service); diff != "" {
+						t.Fatalf("unexpected service (-want +got):\n%s", diff)
+					}
+					if diff := cmp.Diff(systemdObject, op); diff != "" {
+						t.Fatalf("unexpected object path (-want +got):\n%s", diff)
+					}
+					if diff := cmp.Diff([]any{"org.freedesktop.systemd1.Manager", "Version"}, args); diff != "" {
+						t.Fatalf("unexpected arguments (-want +got):\n%s", diff)
+					}
+
+					return dbus.Store([]any{dbus.MakeVariant(tt.version)}, out)
+				},
+			}
+
+			v, err := c.SystemdVersion(context.Background())
+			if tt.ok && err != nil {
+				t.Fatalf("failed to get version: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if diff := cmp.Diff(tt.v, v); diff != "" {
+				t.Fatalf("unexpected version (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -24,19 +24,86 @@ func TestIntegration(t *testing.T) {
 	}
 	defer c.Close()
 
-	props, err := c.Manager.Properties(ctx)
+	props, err := c.Properties(ctx)
 	if err != nil {
 		t.Fatalf("failed to get properties: %v", err)
 	}
 
 	t.Logf("properties: %+v", props)
 
-	links, err := c.Manager.ListLinks(ctx)
+	b, err := c.Manager.DescribeRaw(ctx)
+	if err != nil {
+		t.Fatalf("failed to describe: %v", err)
+	}
+
+	t.Logf("description: %s", b)
+
+	d, err := c.Manager.Describe(ctx)
+	if err != nil {
+		t.Fatalf("failed to decode description: %v", err)
+	}
+
+	t.Logf("typed description: %+v", d)
+
+	status, err := c.Status(ctx)
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+
+	t.Logf("status: %+v", status)
+
+	links, err := c.ListLinks(ctx)
 	if err != nil {
 		t.Fatalf("failed to list links: %v", err)
 	}
 
+	detailed, err := c.ListLinksDetailed(ctx)
+	if err != nil {
+		t.Fatalf("failed to list detailed links: %v", err)
+	}
+
+	for _, l := range detailed {
+		t.Logf("  - detailed link: %+v", l)
+	}
+
 	for _, l := range links {
 		t.Logf("  - link: %+v", l)
+
+		byName, err := c.GetLinkByName(ctx, l.Name)
+		if err != nil {
+			t.Fatalf("failed to get link %q by name: %v", l.Name, err)
+		}
+		if byName != l {
+			t.Fatalf("unexpected link by name: %+v", byName)
+		}
+
+		byIndex, err := c.GetLinkByIndex(ctx, l.Index)
+		if err != nil {
+			t.Fatalf("failed to get link %d by index: %v", l.Index, err)
+		}
+		if byIndex != l {
+			t.Fatalf("unexpected link by index: %+v", byIndex)
+		}
+
+		lprops, err := c.Link(l).Properties(ctx)
+		if err != nil {
+			t.Fatalf("failed to get properties for link %q: %v", l.Name, err)
+		}
+
+		t.Logf("    properties: %+v", lprops)
+
+		b, err := c.Link(l).DescribeRaw(ctx)
+		if err != nil {
+			t.Fatalf("failed to describe link %q: %v", l.Name, err)
+		}
+
+		t.Logf("    description: %s", b)
+
+		d, err := c.Link(l).Describe(ctx)
+		if err != nil {
+			t.Fatalf("failed to decode description for link %q: %v", l.Name, err)
+		}
+
+		t.Logf("    typed description: %+v", d)
 	}
 }
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var unmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// ParseFile parses a File from the INI-like format used by systemd. Comments
+// and blank lines are discarded, and lines ending in a backslash are joined
+// with the following line.
+func ParseFile(r io.Reader) (*File, error) {
+	var (
+		f    File
+		s    *Section
+		cont string
+		n    int
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		// Accumulate continued lines, joining them with a space.
+		if strings.HasSuffix(line, `\`) {
+			cont += strings.TrimSpace(line[:len(line)-1]) + " "
+			continue
+		}
+		line, cont = cont+line, ""
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("config: line %d: malformed section header %q", n, line)
+			}
+
+			f.Sections = append(f.Sections, Section{Name: line[1 : len(line)-1]})
+			s = &f.Sections[len(f.Sections)-1]
+			continue
+		}
+
+		if s == nil {
+			return nil, fmt.Errorf("config: line %d: option %q is not in a section", n, line)
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("config: line %d: missing '=' in option %q", n, line)
+		}
+
+		s.Options = append(s.Options, Option{
+			Key:   strings.TrimSpace(k),
+			Value: strings.TrimSpace(v),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("config: failed to read file: %w", err)
+	}
+
+	return &f, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing f as described by
+// ParseFile.
+func (f *File) UnmarshalText(b []byte) error {
+	pf, err := ParseFile(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	*f = *pf
+	return nil
+}
+
+// decodeSection decodes the options of s into the fields of the struct pointed
+// to by v, using the same keys as encodeSection. As with systemd, the last
+// assignment of an option wins, list options accumulate values, and an empty
+// assignment resets an option to its zero value.
+//
+// Options with unknown keys or values which cannot be decoded are appended to
+// the Extra field verbatim, so that they are preserved when v is encoded.
+func decodeSection(s Section, v any) {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+
+	var (
+		fields = make(map[string]int)
+		extra  reflect.Value
+	)
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Type == optionsType && f.Name == "Extra" {
+			extra = rv.Field(i)
+			continue
+		}
+
+		key, _ := fieldKey(f)
+		fields[key] = i
+	}
+
+	for _, o := range s.Options {
+		i, ok := fields[o.Key]
+		if ok && decodeValues(rv.Field(i), o.Value) {
+			continue
+		}
+
+		if extra.IsValid() {
+			extra.Set(reflect.Append(extra, reflect.ValueOf(o)))
+		}
+	}
+}
+
+// decodeValues decodes a single option value into v, appending to v if it is
+// a slice. It reports whether the value could be decoded.
+func decodeValues(v reflect.Value, s string) bool {
+	if s == "" {
+		v.SetZero()
+		return true
+	}
+
+	if v.Type() != hwAddrType && v.Kind() == reflect.Slice {
+		// List values are separated by whitespace.
+		values := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, f := range strings.Fields(s) {
+			ev := reflect.New(v.Type().Elem()).Elem()
+			if !decodeValue(ev, f) {
+				return false
+			}
+
+			values = reflect.Append(values, ev)
+		}
+
+		v.Set(reflect.AppendSlice(v, values))
+		return true
+	}
+
+	nv := reflect.New(v.Type()).Elem()
+	if !decodeValue(nv, s) {
+		return false
+	}
+
+	v.Set(nv)
+	return true
+}
+
+// decodeValue decodes a single option value into the addressable value v.
+func decodeValue(v reflect.Value, s string) bool {
+	if v.Kind() == reflect.Pointer {
+		ev := reflect.New(v.Type().Elem())
+		if !decodeValue(ev.Elem(), s) {
+			return false
+		}
+
+		v.Set(ev)
+		return true
+	}
+
+	switch {
+	case v.Type() == durationType:
+		d, ok := parseDuration(s)
+		v.SetInt(int64(d))
+		return ok
+	case v.Type() == hwAddrType:
+		mac, err := net.ParseMAC(s)
+		v.SetBytes(mac)
+		return err == nil
+	case reflect.PointerTo(v.Type()).Implements(unmarshalType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)) == nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return true
+	case reflect.Bool:
+		b, ok := parseBool(s)
+		v.SetBool(b)
+		return ok
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(n)
+		return err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(n)
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// parseBool parses a systemd boolean value.
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "1", "yes", "y", "true", "t", "on":
+		return true, true
+	case "0", "no", "n", "false", "f", "off":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// timeUnits are the units accepted in systemd time spans.
+var timeUnits = map[string]time.Duration{
+	"us": time.Microsecond, "usec": time.Microsecond, "µs": time.Microsecond,
+	"ms": time.Millisecond, "msec": time.Millisecond,
+	"": time.Second, "s": time.Second, "sec": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// parseDuration parses a systemd time span such as "90", "1min 30s", or
+// "100ms". Values without a unit are interpreted as seconds.
+func parseDuration(s string) (time.Duration, bool) {
+	var d time.Duration
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+
+	for s != "" {
+		// Each component is a number followed by an optional unit.
+		i := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if i == -1 {
+			i = len(s)
+		}
+		if i == 0 {
+			return 0, false
+		}
+
+		n, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, false
+		}
+		s = strings.TrimLeft(s[i:], " ")
+
+		j := strings.IndexAny(s, " 0123456789.")
+		if j == -1 {
+			j = len(s)
+		}
+
+		unit, ok := timeUnits[s[:j]]
+		if !ok {
+			return 0, false
+		}
+		s = strings.TrimLeft(s[j:], " ")
+
+		d += time.Duration(n * float64(unit))
+	}
+
+	return d, true
+}
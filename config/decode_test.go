@@ -0,0 +1,299 @@
+package config
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		f    *File
+		ok   bool
+	}{
+		{
+			name: "no section",
+			s:    "Name=eth0",
+		},
+		{
+			name: "malformed section",
+			s:    "[Match",
+		},
+		{
+			name: "missing equals",
+			s:    "[Match]\nName",
+		},
+		{
+			name: "empty",
+			f:    &File{},
+			ok:   true,
+		},
+		{
+			name: "OK",
+			s: `
+# A comment.
+[Match]
+Name = eth0
+
+; Another comment.
+[Network]
+DNS=192.0.2.53 \
+    2001:db8::53
+Domains=
+`,
+			f: &File{Sections: []Section{
+				{
+					Name:    "Match",
+					Options: []Option{{Key: "Name", Value: "eth0"}},
+				},
+				{
+					Name: "Network",
+					Options: []Option{
+						{Key: "DNS", Value: "192.0.2.53 2001:db8::53"},
+						{Key: "Domains"},
+					},
+				},
+			}},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFile(strings.NewReader(tt.s))
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse file: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if diff := cmp.Diff(tt.f, f); diff != "" {
+				t.Fatalf("unexpected file (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNetworkUnmarshalText(t *testing.T) {
+	const s = `
+[Match]
+Name=eth0 eth1
+
+[Network]
+DHCP=ipv4
+IPv6AcceptRA=off
+DNS=192.0.2.53
+DNS=2001:db8::53 192.0.2.54
+NTP=ntp.example.com
+NTP=
+KeepConfiguration=static
+
+[Address]
+Address=192.0.2.10/24
+
+[Route]
+Gateway=_dhcp4
+Metric=100
+
+[DHCPv4]
+UseDNS=true
+FallbackLeaseLifetime=1min 30s
+
+[IPv6AcceptRA]
+UseDNS=no
+`
+
+	var n Network
+	if err := n.UnmarshalText([]byte(s)); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	want := Network{
+		Match: Match{Name: []string{"eth0", "eth1"}},
+		Network: NetworkSection{
+			DHCP:         "ipv4",
+			IPv6AcceptRA: Bool(false),
+			DNS:          []string{"192.0.2.53", "2001:db8::53", "192.0.2.54"},
+			Extra:        []Option{{Key: "KeepConfiguration", Value: "static"}},
+		},
+		Addresses: []Address{{Address: netip.MustParsePrefix("192.0.2.10/24")}},
+		// Special values which cannot be represented by a field are preserved.
+		Routes: []Route{{
+			Metric: 100,
+			Extra:  []Option{{Key: "Gateway", Value: "_dhcp4"}},
+		}},
+		DHCPv4: DHCPv4{
+			UseDNS:                Bool(true),
+			FallbackLeaseLifetime: 90 * time.Second,
+		},
+		Extra: []Section{{
+			Name:    "IPv6AcceptRA",
+			Options: []Option{{Key: "UseDNS", Value: "no"}},
+		}},
+	}
+
+	if diff := cmp.Diff(want, n, testComparers()...); diff != "" {
+		t.Fatalf("unexpected network (-want +got):\n%s", diff)
+	}
+}
+
+func TestNetDevUnmarshalText(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    NetDev
+	}{
+		{
+			name: "VLAN",
+			s: `
+[NetDev]
+Name=vlan10
+Kind=vlan
+
+[VLAN]
+Id=10
+GVRP=yes
+`,
+			n: NetDev{
+				NetDev: NetDevSection{Name: "vlan10"},
+				Kind:   &VLAN{ID: 10, GVRP: Bool(true)},
+			},
+		},
+		{
+			name: "veth",
+			s: `
+[NetDev]
+Name=veth0
+Kind=veth
+MACAddress=00:11:22:33:44:55
+
+[Peer]
+Name=veth1
+`,
+			n: NetDev{
+				NetDev: NetDevSection{
+					Name:       "veth0",
+					MACAddress: net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+				},
+				Kind: &Veth{Name: "veth1"},
+			},
+		},
+		{
+			name: "other",
+			s: `
+[NetDev]
+Name=wg0
+Kind=wireguard
+
+[WireGuard]
+ListenPort=51820
+`,
+			n: NetDev{
+				NetDev: NetDevSection{Name: "wg0"},
+				Kind:   OtherKind("wireguard"),
+				Extra: []Section{{
+					Name:    "WireGuard",
+					Options: []Option{{Key: "ListenPort", Value: "51820"}},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n NetDev
+			if err := n.UnmarshalText([]byte(tt.s)); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.n, n); diff != "" {
+				t.Fatalf("unexpected netdev (-want +got):\n%s", diff)
+			}
+
+			// The file must survive a round trip, ignoring formatting.
+			b, err := n.MarshalText()
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+
+			if diff := cmp.Diff(strings.TrimPrefix(tt.s, "\n"), string(b)); diff != "" {
+				t.Fatalf("unexpected file (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLinkUnmarshalText(t *testing.T) {
+	const s = `[Match]
+OriginalName=*
+
+[Link]
+NamePolicy=keep kernel database onboard slot path
+WakeOnLan=off
+TCPSegmentationOffload=false
+RxBufferSize=max
+`
+
+	var l Link
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	want := Link{
+		Match: Match{OriginalName: []string{"*"}},
+		Link: LinkSection{
+			NamePolicy:             []string{"keep", "kernel", "database", "onboard", "slot", "path"},
+			WakeOnLan:              []string{"off"},
+			TCPSegmentationOffload: Bool(false),
+			Extra:                  []Option{{Key: "RxBufferSize", Value: "max"}},
+		},
+	}
+
+	if diff := cmp.Diff(want, l); diff != "" {
+		t.Fatalf("unexpected link (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		s  string
+		d  time.Duration
+		ok bool
+	}{
+		{s: ""},
+		{s: "infinity"},
+		{s: "5 parsecs"},
+		{s: "90", d: 90 * time.Second, ok: true},
+		{s: "100ms", d: 100 * time.Millisecond, ok: true},
+		{s: "1.5s", d: 1500 * time.Millisecond, ok: true},
+		{s: "1h 2min 3s", d: time.Hour + 2*time.Minute + 3*time.Second, ok: true},
+		{s: "2 days", d: 48 * time.Hour, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			d, ok := parseDuration(tt.s)
+			if diff := cmp.Diff(tt.ok, ok); diff != "" {
+				t.Fatalf("unexpected OK (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.d, d); diff != "" {
+				t.Fatalf("unexpected duration (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func testComparers() []cmp.Option {
+	return []cmp.Option{
+		cmp.Comparer(func(x, y netip.Addr) bool { return x == y }),
+		cmp.Comparer(func(x, y netip.Prefix) bool { return x == y }),
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Dirs are the directories which networkd searches for configuration files, in
+// order of decreasing priority.
+var Dirs = []string{
+	"/etc/systemd/network",
+	"/run/systemd/network",
+	"/usr/local/lib/systemd/network",
+	"/usr/lib/systemd/network",
+}
+
+// A Unit is a typed configuration file: *Network, *NetDev, or *Link.
+type Unit interface {
+	File() (*File, error)
+	decode(f *File)
+}
+
+var (
+	_ Unit = &Network{}
+	_ Unit = &NetDev{}
+	_ Unit = &Link{}
+)
+
+// Decode decodes f into u, replacing its contents. Unknown sections and
+// options, and options with values which cannot be represented by u's fields,
+// are preserved in u's Extra fields.
+func (f *File) Decode(u Unit) { u.decode(f) }
+
+// Merge concatenates the sections of each File in order, as networkd reads a
+// configuration file and its drop-ins. The returned File may contain repeated
+// sections and options. They are resolved when it is decoded: a later option
+// overrides an earlier option with the same key, except for list options,
+// which accumulate values until reset by an empty assignment.
+func Merge(files ...*File) *File {
+	var out File
+	for _, f := range files {
+		for _, s := range f.Sections {
+			out.Sections = append(out.Sections, Section{
+				Name:    s.Name,
+				Options: slices.Clone(s.Options),
+			})
+		}
+	}
+
+	return &out
+}
+
+// A DropIn is a drop-in file, such as foo.network.d/override.conf, which
+// modifies the configuration file it is named for.
+type DropIn struct {
+	// Name is the file name of the drop-in, such as "override.conf".
+	Name string
+
+	// Path is the full path to the drop-in.
+	Path string
+
+	File *File
+}
+
+// ReadFile reads and parses the configuration file at path.
+func ReadFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	pf, err := ParseFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("config: failed to parse %q: %w", path, err)
+	}
+
+	return pf, nil
+}
+
+// ReadDropIns reads the drop-ins for the configuration file name, such as
+// "10-eth0.network", from dirs in order of decreasing priority. If dirs is
+// empty, Dirs is used.
+//
+// The drop-ins are returned in the order in which networkd applies them: sorted
+// by name, with a drop-in in a higher priority directory masking any drop-ins
+// of the same name in lower priority directories.
+func ReadDropIns(dirs []string, name string) ([]DropIn, error) {
+	if len(dirs) == 0 {
+		dirs = Dirs
+	}
+
+	paths := make(map[string]string)
+	for _, dir := range dirs {
+		dropins := filepath.Join(dir, name+".d")
+		entries, err := os.ReadDir(dropins)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, fmt.Errorf("config: failed to read drop-ins: %w", err)
+		}
+
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".conf") {
+				continue
+			}
+			if _, ok := paths[e.Name()]; ok {
+				// Masked by a higher priority directory.
+				continue
+			}
+
+			paths[e.Name()] = filepath.Join(dropins, e.Name())
+		}
+	}
+
+	names := make([]string, 0, len(paths))
+	for n := range paths {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+
+	dropins := make([]DropIn, 0, len(names))
+	for _, n := range names {
+		f, err := ReadFile(paths[n])
+		if err != nil {
+			return nil, err
+		}
+
+		dropins = append(dropins, DropIn{Name: n, Path: paths[n], File: f})
+	}
+
+	return dropins, nil
+}
+
+// ReadUnit reads the configuration file name from the highest priority
+// directory in dirs which contains it, and merges it with its drop-ins as
+// described by ReadDropIns and Merge. If dirs is empty, Dirs is used. If no
+// directory contains the file, the error wraps fs.ErrNotExist.
+func ReadUnit(dirs []string, name string) (*File, error) {
+	if len(dirs) == 0 {
+		dirs = Dirs
+	}
+
+	var f *File
+	for _, dir := range dirs {
+		pf, err := ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		f = pf
+		break
+	}
+	if f == nil {
+		return nil, fmt.Errorf("config: failed to find %q: %w", name, fs.ErrNotExist)
+	}
+
+	dropins, err := ReadDropIns(dirs, name)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []*File{f}
+	for _, d := range dropins {
+		files = append(files, d.File)
+	}
+
+	return Merge(files...), nil
+}
+
+// WriteDropIn writes u as the drop-in dropin, such as "override.conf", for the
+// configuration file name in dir, creating the drop-in directory if necessary.
+// The ".conf" suffix is added to dropin if it is not present. The file is
+// replaced atomically.
+//
+// Only the options which should be changed should be set in u, as every option
+// set in u overrides or adds to the options of the configuration file.
+func WriteDropIn(dir, name, dropin string, u Unit) error {
+	if !strings.HasSuffix(dropin, ".conf") {
+		dropin += ".conf"
+	}
+
+	dir = filepath.Join(dir, name+".d")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("config: failed to create drop-in directory: %w", err)
+	}
+
+	return WriteUnit(filepath.Join(dir, dropin), u)
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadUnit(t *testing.T) {
+	var (
+		etc = t.TempDir()
+		usr = t.TempDir()
+	)
+
+	write := func(path, s string) {
+		t.Helper()
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	// The vendor file is masked by the file in /etc, and the vendor's 10-mtu.conf
+	// is masked by the drop-in of the same name in /etc.
+	write(filepath.Join(usr, "10-eth0.network"), "[Match]\nName=vendor\n")
+	write(filepath.Join(etc, "10-eth0.network"), "[Match]\nName=eth0\n\n[Network]\nDHCP=yes\nDNS=192.0.2.53\n")
+	write(filepath.Join(usr, "10-eth0.network.d", "10-mtu.conf"), "[Link]\nMTUBytes=1400\n")
+	write(filepath.Join(usr, "10-eth0.network.d", "20-dns.conf"), "[Network]\nDNS=\nDNS=192.0.2.54\n")
+	write(filepath.Join(usr, "10-eth0.network.d", "README"), "ignored")
+	write(filepath.Join(etc, "10-eth0.network.d", "10-mtu.conf"), "[Link]\nMTUBytes=9000\n")
+
+	// Replace the DHCP setting using a drop-in which sorts last.
+	err := WriteDropIn(etc, "10-eth0.network", "override", &Network{
+		Network: NetworkSection{DHCP: "ipv4"},
+	})
+	if err != nil {
+		t.Fatalf("failed to write drop-in: %v", err)
+	}
+
+	dirs := []string{etc, usr}
+	dropins, err := ReadDropIns(dirs, "10-eth0.network")
+	if err != nil {
+		t.Fatalf("failed to read drop-ins: %v", err)
+	}
+
+	var paths []string
+	for _, d := range dropins {
+		paths = append(paths, d.Path)
+	}
+
+	wantPaths := []string{
+		filepath.Join(etc, "10-eth0.network.d", "10-mtu.conf"),
+		filepath.Join(usr, "10-eth0.network.d", "20-dns.conf"),
+		filepath.Join(etc, "10-eth0.network.d", "override.conf"),
+	}
+	if diff := cmp.Diff(wantPaths, paths); diff != "" {
+		t.Fatalf("unexpected drop-ins (-want +got):\n%s", diff)
+	}
+
+	f, err := ReadUnit(dirs, "10-eth0.network")
+	if err != nil {
+		t.Fatalf("failed to read unit: %v", err)
+	}
+
+	var n Network
+	f.Decode(&n)
+
+	want := Network{
+		Match: Match{Name: []string{"eth0"}},
+		Link:  NetworkLink{MTUBytes: 9000},
+		Network: NetworkSection{
+			DHCP: "ipv4",
+			DNS:  []string{"192.0.2.54"},
+		},
+	}
+	if diff := cmp.Diff(want, n, testComparers()...); diff != "" {
+		t.Fatalf("unexpected network (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadUnit(dirs, "20-eth1.network"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not exist error, but got: %v", err)
+	}
+}
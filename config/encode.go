@@ -0,0 +1,215 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types with special handling when encoding options.
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	hwAddrType   = reflect.TypeOf(net.HardwareAddr(nil))
+	optionsType  = reflect.TypeOf([]Option(nil))
+	marshalType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// An encoder accumulates the sections of a File, retaining the first error
+// which occurs.
+type encoder struct {
+	f   File
+	err error
+}
+
+// section encodes the struct v as a Section named name.
+func (e *encoder) section(name string, v any) {
+	e.add(encodeSection(name, v))
+}
+
+// add adds a Section produced by encodeSection.
+func (e *encoder) add(s Section, err error) {
+	if e.err != nil {
+		return
+	}
+	if err != nil {
+		e.err = err
+		return
+	}
+
+	e.f.Sections = append(e.f.Sections, s)
+}
+
+// file appends the extra sections and returns the encoded File.
+func (e *encoder) file(extra []Section) (*File, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	e.f.Sections = append(e.f.Sections, extra...)
+	return &e.f, nil
+}
+
+// encodeSection produces a Section named name from the fields of the struct v.
+// Each field is encoded as an option whose key is the field's name, or the
+// value of its `config` struct tag. Fields with zero values are omitted, and
+// slices produce one option per element, or a single space-separated option if
+// the tag option "space" is set. A field of type []Option named Extra is
+// appended verbatim.
+func encodeSection(name string, v any) (Section, error) {
+	s := Section{Name: name}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	rt := rv.Type()
+
+	var extra []Option
+	for i := 0; i < rt.NumField(); i++ {
+		f, fv := rt.Field(i), rv.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Type == optionsType && f.Name == "Extra" {
+			extra = fv.Interface().([]Option)
+			continue
+		}
+
+		key, space := fieldKey(f)
+		values, err := encodeValues(fv)
+		if err != nil {
+			return Section{}, fmt.Errorf("config: [%s] %s: %v", name, key, err)
+		}
+
+		// Some options are lists which must be assigned in a single
+		// space-separated value.
+		if space && len(values) > 0 {
+			values = []string{strings.Join(values, " ")}
+		}
+
+		for _, v := range values {
+			s.Options = append(s.Options, Option{Key: key, Value: v})
+		}
+	}
+
+	for _, o := range extra {
+		if err := checkText(o.Key); err != nil {
+			return Section{}, fmt.Errorf("config: [%s] key: %v", name, err)
+		}
+		if err := checkText(o.Value); err != nil {
+			return Section{}, fmt.Errorf("config: [%s] %s: %v", name, o.Key, err)
+		}
+	}
+
+	s.Options = append(s.Options, extra...)
+	return s, nil
+}
+
+// fieldKey returns the option key for a struct field, and whether the tag
+// option "space" is set for space-separated list options.
+func fieldKey(f reflect.StructField) (key string, space bool) {
+	key, opts, _ := strings.Cut(f.Tag.Get("config"), ",")
+	if key == "" {
+		key = f.Name
+	}
+
+	return key, opts == "space"
+}
+
+// encodeValues encodes the value of a single field as zero or more option
+// values.
+func encodeValues(v reflect.Value) ([]string, error) {
+	if v.IsZero() {
+		return nil, nil
+	}
+
+	// Check for slices which are encoded as a single value before slices of
+	// multiple values.
+	if v.Type() != hwAddrType && v.Kind() == reflect.Slice {
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s, err := encodeValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, s)
+		}
+
+		return values, nil
+	}
+
+	s, err := encodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{s}, nil
+}
+
+// encodeValue encodes a single option value, which must not contain control
+// characters.
+func encodeValue(v reflect.Value) (string, error) {
+	s, err := formatValue(v)
+	if err != nil {
+		return "", err
+	}
+	if err := checkText(s); err != nil {
+		return "", err
+	}
+
+	return s, nil
+}
+
+// formatValue formats a single option value as text.
+func formatValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == durationType:
+		return formatDuration(time.Duration(v.Int())), nil
+	case v.Type() == hwAddrType:
+		return v.Interface().(net.HardwareAddr).String(), nil
+	case v.Type().Implements(marshalType):
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "yes", nil
+		}
+		return "no", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+// formatDuration formats d as a systemd time span in the largest unit which
+// represents it exactly.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	case d%time.Millisecond == 0:
+		return strconv.FormatInt(int64(d/time.Millisecond), 10) + "ms"
+	default:
+		return strconv.FormatInt(int64(d/time.Microsecond), 10) + "us"
+	}
+}
+
+// Bool returns a pointer to b, for use with optional boolean options.
+func Bool(b bool) *bool { return &b }
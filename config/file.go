@@ -0,0 +1,129 @@
+// Package config generates and parses systemd-networkd configuration files,
+// such as .network, .netdev, and .link files, using Go types.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// A File is a systemd unit file, consisting of sections of key and value
+// options. A File is the untyped representation of a configuration file, and
+// is produced and consumed by the typed configuration types in this package.
+type File struct {
+	Sections []Section
+}
+
+// A Section is a named section of a File, such as [Network].
+type Section struct {
+	Name    string
+	Options []Option
+}
+
+// An Option is a single key and value assignment in a Section. Keys may be
+// repeated within a section.
+type Option struct {
+	Key, Value string
+}
+
+// MarshalText implements encoding.TextMarshaler, rendering f in the INI-like
+// format used by systemd. Empty sections are omitted. Section names, keys and
+// values which contain control characters, such as newlines, cannot be
+// represented and produce an error.
+func (f *File) MarshalText() ([]byte, error) {
+	var b bytes.Buffer
+	for _, s := range f.Sections {
+		if len(s.Options) == 0 {
+			continue
+		}
+
+		if err := checkText(s.Name); err != nil {
+			return nil, fmt.Errorf("config: section name: %v", err)
+		}
+		for _, o := range s.Options {
+			if err := checkText(o.Key); err != nil {
+				return nil, fmt.Errorf("config: [%s] key: %v", s.Name, err)
+			}
+			if err := checkText(o.Value); err != nil {
+				return nil, fmt.Errorf("config: [%s] %s: %v", s.Name, o.Key, err)
+			}
+		}
+
+		// Separate each section with a blank line.
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+
+		b.WriteString("[" + s.Name + "]\n")
+		for _, o := range s.Options {
+			b.WriteString(o.Key + "=" + o.Value + "\n")
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// checkText reports an error if s contains a control character, which would
+// break the line structure of a unit file.
+func checkText(s string) error {
+	if strings.IndexFunc(s, unicode.IsControl) >= 0 {
+		return fmt.Errorf("%q contains a control character", s)
+	}
+
+	return nil
+}
+
+// WriteTo implements io.WriterTo, writing f to w as rendered by MarshalText.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	b, err := f.MarshalText()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// WriteUnit renders u and writes it to path, replacing any existing file
+// atomically.
+func WriteUnit(path string, u Unit) error {
+	f, err := u.File()
+	if err != nil {
+		return err
+	}
+
+	b, err := f.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file in the same directory so the rename is atomic.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("config: failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("config: failed to write %q: %w", path, err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("config: failed to write %q: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("config: failed to write %q: %w", path, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("config: failed to write %q: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"bytes"
+	"net"
+)
+
+// A Link is a .link file, which is applied by udev to configure the links it
+// matches when they appear. See systemd.link(5) for the meaning of each option.
+type Link struct {
+	Match Match
+	Link  LinkSection
+
+	// Extra contains any additional sections, which are appended verbatim.
+	Extra []Section
+}
+
+// A LinkSection is the [Link] section of a .link file.
+type LinkSection struct {
+	Description            string
+	Alias                  string
+	MACAddressPolicy       string
+	MACAddress             net.HardwareAddr
+	NamePolicy             []string `config:",space"`
+	Name                   string
+	AlternativeNamesPolicy []string `config:",space"`
+	AlternativeName        []string
+	MTUBytes               int
+	BitsPerSecond          string
+	Duplex                 string
+	AutoNegotiation        *bool
+	WakeOnLan              []string `config:",space"`
+	Port                   string
+
+	// Offload settings.
+	ReceiveChecksumOffload     *bool
+	TransmitChecksumOffload    *bool
+	TCPSegmentationOffload     *bool
+	TCP6SegmentationOffload    *bool
+	GenericSegmentationOffload *bool
+	GenericReceiveOffload      *bool
+	LargeReceiveOffload        *bool
+
+	// Channel and ring buffer settings.
+	RxChannels       uint32
+	TxChannels       uint32
+	OtherChannels    uint32
+	CombinedChannels uint32
+	RxBufferSize     uint32
+	TxBufferSize     uint32
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// File produces the File representation of l.
+func (l *Link) File() (*File, error) {
+	var e encoder
+	e.section("Match", l.Match)
+	e.section("Link", l.Link)
+
+	return e.file(l.Extra)
+}
+
+// MarshalText implements encoding.TextMarshaler, rendering l as a .link file.
+func (l *Link) MarshalText() ([]byte, error) {
+	f, err := l.File()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing a .link file into
+// l. Unknown sections and options, and options with values which cannot be
+// represented by l's fields, are preserved in the Extra fields.
+func (l *Link) UnmarshalText(b []byte) error {
+	f, err := ParseFile(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	l.decode(f)
+	return nil
+}
+
+// decode decodes the sections of f into l.
+func (l *Link) decode(f *File) {
+	*l = Link{}
+	for _, s := range f.Sections {
+		switch s.Name {
+		case "Match":
+			decodeSection(s, &l.Match)
+		case "Link":
+			decodeSection(s, &l.Link)
+		default:
+			l.Extra = append(l.Extra, s)
+		}
+	}
+}
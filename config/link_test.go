@@ -0,0 +1,49 @@
+package config
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLinkMarshalText(t *testing.T) {
+	l := Link{
+		Match: Match{
+			OriginalName: []string{"en*"},
+			MACAddress:   []net.HardwareAddr{{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}},
+		},
+		Link: LinkSection{
+			MACAddressPolicy:       "persistent",
+			NamePolicy:             []string{"kernel", "database", "onboard", "path"},
+			AlternativeNamesPolicy: []string{"path"},
+			WakeOnLan:              []string{"magic", "unicast"},
+			GenericReceiveOffload:  Bool(true),
+			LargeReceiveOffload:    Bool(false),
+			RxBufferSize:           4096,
+		},
+	}
+
+	b, err := l.MarshalText()
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	want := `[Match]
+OriginalName=en*
+MACAddress=00:11:22:33:44:55
+
+[Link]
+MACAddressPolicy=persistent
+NamePolicy=kernel database onboard path
+AlternativeNamesPolicy=path
+WakeOnLan=magic unicast
+GenericReceiveOffload=yes
+LargeReceiveOffload=no
+RxBufferSize=4096
+`
+
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Fatalf("unexpected file (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+// A NetDev is a .netdev file, which creates a virtual network device. See
+// systemd.netdev(5) for the meaning of each option.
+type NetDev struct {
+	Match  Match
+	NetDev NetDevSection
+
+	// Kind is the kind of virtual network device and its kind-specific
+	// configuration, which determines the Kind option of the [NetDev] section.
+	// Kind must be set.
+	Kind NetDevKind
+
+	// Extra contains any additional sections, which are appended verbatim.
+	Extra []Section
+}
+
+// A NetDevSection is the [NetDev] section of a .netdev file. The Kind option
+// is set by NetDev.Kind.
+type NetDevSection struct {
+	Description string
+	Name        string
+	MTUBytes    int
+	MACAddress  net.HardwareAddr
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A NetDevKind is the kind-specific configuration of a NetDev. The concrete
+// types are Bridge, Bond, VLAN, VXLAN, Dummy, Veth, and OtherKind.
+type NetDevKind interface {
+	// kind returns the value of the Kind option and the name of the section
+	// for the kind-specific configuration, if any.
+	kind() (kind, section string)
+}
+
+var (
+	_ NetDevKind = &Bridge{}
+	_ NetDevKind = &Bond{}
+	_ NetDevKind = &VLAN{}
+	_ NetDevKind = &VXLAN{}
+	_ NetDevKind = &Dummy{}
+	_ NetDevKind = &Veth{}
+	_ NetDevKind = OtherKind("")
+)
+
+// A Bridge is a bridge device, configured by the [Bridge] section.
+type Bridge struct {
+	HelloTimeSec      time.Duration
+	MaxAgeSec         time.Duration
+	ForwardDelaySec   time.Duration
+	AgeingTimeSec     time.Duration
+	Priority          uint16
+	DefaultPVID       string
+	VLANFiltering     *bool
+	STP               *bool
+	MulticastSnooping *bool
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A Bond is a bond device, configured by the [Bond] section.
+type Bond struct {
+	Mode                  string
+	TransmitHashPolicy    string
+	LACPTransmitRate      string
+	AdSelect              string
+	MIIMonitorSec         time.Duration
+	UpDelaySec            time.Duration
+	DownDelaySec          time.Duration
+	ARPIntervalSec        time.Duration
+	ARPIPTargets          []netip.Addr
+	PrimaryReselectPolicy string
+	MinLinks              int
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A VLAN is a VLAN device, configured by the [VLAN] section.
+type VLAN struct {
+	ID            uint16 `config:"Id"`
+	Protocol      string
+	GVRP          *bool
+	MVRP          *bool
+	LooseBinding  *bool
+	ReorderHeader *bool
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A VXLAN is a VXLAN device, configured by the [VXLAN] section.
+type VXLAN struct {
+	VNI             uint32
+	Remote          netip.Addr
+	Local           netip.Addr
+	Group           netip.Addr
+	TOS             uint8
+	TTL             uint8
+	MacLearning     *bool
+	DestinationPort uint16
+	PortRange       string
+	Independent     *bool
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A Dummy is a dummy device, which has no kind-specific configuration.
+type Dummy struct{}
+
+// A Veth is a virtual Ethernet device pair, whose peer is configured by the
+// [Peer] section.
+type Veth struct {
+	Name       string
+	MACAddress net.HardwareAddr
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// An OtherKind is a device kind which has no typed configuration in this
+// package, such as "wireguard". Its kind-specific sections may be set using
+// NetDev.Extra.
+type OtherKind string
+
+func (*Bridge) kind() (string, string)     { return "bridge", "Bridge" }
+func (*Bond) kind() (string, string)       { return "bond", "Bond" }
+func (*VLAN) kind() (string, string)       { return "vlan", "VLAN" }
+func (*VXLAN) kind() (string, string)      { return "vxlan", "VXLAN" }
+func (*Dummy) kind() (string, string)      { return "dummy", "" }
+func (*Veth) kind() (string, string)       { return "veth", "Peer" }
+func (k OtherKind) kind() (string, string) { return string(k), "" }
+
+// File produces the File representation of n.
+func (n *NetDev) File() (*File, error) {
+	if n.Kind == nil {
+		return nil, errors.New("config: [NetDev] Kind must be set")
+	}
+	kind, section := n.Kind.kind()
+
+	var e encoder
+	e.section("Match", n.Match)
+
+	// Place the Kind option immediately after Name, as is conventional.
+	s, err := encodeSection("NetDev", n.NetDev)
+	i := slices.IndexFunc(s.Options, func(o Option) bool { return o.Key == "Name" })
+	s.Options = slices.Insert(s.Options, i+1, Option{Key: "Kind", Value: kind})
+	e.add(s, err)
+
+	if section != "" {
+		e.section(section, n.Kind)
+	}
+
+	return e.file(n.Extra)
+}
+
+// MarshalText implements encoding.TextMarshaler, rendering n as a .netdev
+// file.
+func (n *NetDev) MarshalText() ([]byte, error) {
+	f, err := n.File()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing a .netdev file
+// into n. Unknown sections and options, and options with values which cannot
+// be represented by n's fields, are preserved in the Extra fields. Kinds other
+// than those with typed configuration are parsed as OtherKind.
+func (n *NetDev) UnmarshalText(b []byte) error {
+	f, err := ParseFile(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	n.decode(f)
+	return nil
+}
+
+// decode decodes the sections of f into n.
+func (n *NetDev) decode(f *File) {
+	*n = NetDev{}
+
+	// The kind must be known before its section can be decoded.
+	var kind string
+	for _, s := range f.Sections {
+		if s.Name != "NetDev" {
+			continue
+		}
+		for _, o := range s.Options {
+			if o.Key == "Kind" {
+				kind = o.Value
+			}
+		}
+	}
+
+	var section string
+	switch kind {
+	case "":
+	case "bridge":
+		n.Kind = &Bridge{}
+	case "bond":
+		n.Kind = &Bond{}
+	case "vlan":
+		n.Kind = &VLAN{}
+	case "vxlan":
+		n.Kind = &VXLAN{}
+	case "dummy":
+		n.Kind = &Dummy{}
+	case "veth":
+		n.Kind = &Veth{}
+	default:
+		n.Kind = OtherKind(kind)
+	}
+	if n.Kind != nil {
+		_, section = n.Kind.kind()
+	}
+
+	for _, s := range f.Sections {
+		switch {
+		case s.Name == "Match":
+			decodeSection(s, &n.Match)
+		case s.Name == "NetDev":
+			s.Options = slices.DeleteFunc(slices.Clone(s.Options), func(o Option) bool {
+				return o.Key == "Kind"
+			})
+			decodeSection(s, &n.NetDev)
+		case section != "" && s.Name == section:
+			decodeSection(s, n.Kind)
+		default:
+			n.Extra = append(n.Extra, s)
+		}
+	}
+}
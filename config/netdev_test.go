@@ -0,0 +1,163 @@
+package config
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNetDevMarshalText(t *testing.T) {
+	tests := []struct {
+		name string
+		n    NetDev
+		s    string
+		ok   bool
+	}{
+		{
+			name: "no kind",
+			n:    NetDev{NetDev: NetDevSection{Name: "br0"}},
+		},
+		{
+			name: "bridge",
+			n: NetDev{
+				NetDev: NetDevSection{Description: "LAN bridge", Name: "br0"},
+				Kind: &Bridge{
+					ForwardDelaySec: 4 * time.Second,
+					STP:             Bool(true),
+					VLANFiltering:   Bool(false),
+				},
+			},
+			s: `
+[NetDev]
+Description=LAN bridge
+Name=br0
+Kind=bridge
+
+[Bridge]
+ForwardDelaySec=4s
+VLANFiltering=no
+STP=yes
+`,
+			ok: true,
+		},
+		{
+			name: "bond",
+			n: NetDev{
+				NetDev: NetDevSection{Name: "bond0"},
+				Kind: &Bond{
+					Mode:          "802.3ad",
+					MIIMonitorSec: 100 * time.Millisecond,
+					ARPIPTargets: []netip.Addr{
+						netip.MustParseAddr("192.0.2.1"),
+						netip.MustParseAddr("192.0.2.2"),
+					},
+				},
+			},
+			s: `
+[NetDev]
+Name=bond0
+Kind=bond
+
+[Bond]
+Mode=802.3ad
+MIIMonitorSec=100ms
+ARPIPTargets=192.0.2.1
+ARPIPTargets=192.0.2.2
+`,
+			ok: true,
+		},
+		{
+			name: "VLAN",
+			n: NetDev{
+				NetDev: NetDevSection{Name: "vlan10", MTUBytes: 1500},
+				Kind:   &VLAN{ID: 10},
+			},
+			s: `
+[NetDev]
+Name=vlan10
+Kind=vlan
+MTUBytes=1500
+
+[VLAN]
+Id=10
+`,
+			ok: true,
+		},
+		{
+			name: "VXLAN",
+			n: NetDev{
+				NetDev: NetDevSection{Name: "vx0"},
+				Kind: &VXLAN{
+					VNI:             42,
+					Local:           netip.MustParseAddr("2001:db8::1"),
+					DestinationPort: 4789,
+					Extra:           []Option{{Key: "GenericProtocolExtension", Value: "yes"}},
+				},
+			},
+			s: `
+[NetDev]
+Name=vx0
+Kind=vxlan
+
+[VXLAN]
+VNI=42
+Local=2001:db8::1
+DestinationPort=4789
+GenericProtocolExtension=yes
+`,
+			ok: true,
+		},
+		{
+			name: "dummy",
+			n: NetDev{
+				Match:  Match{Host: "server"},
+				NetDev: NetDevSection{Name: "dummy0"},
+				Kind:   &Dummy{},
+			},
+			s: `
+[Match]
+Host=server
+
+[NetDev]
+Name=dummy0
+Kind=dummy
+`,
+			ok: true,
+		},
+		{
+			name: "veth",
+			n: NetDev{
+				NetDev: NetDevSection{Name: "veth0"},
+				Kind:   &Veth{Name: "veth1"},
+			},
+			s: `
+[NetDev]
+Name=veth0
+Kind=veth
+
+[Peer]
+Name=veth1
+`,
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.n.MarshalText()
+			if tt.ok && err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if diff := cmp.Diff(strings.TrimPrefix(tt.s, "\n"), string(b)); diff != "" {
+				t.Fatalf("unexpected file (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
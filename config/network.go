@@ -0,0 +1,238 @@
+package config
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// A Network is a .network file, which configures the links it matches. See
+// systemd.network(5) for the meaning of each option.
+//
+// Each section is omitted if none of its options are set, and each option is
+// omitted if it has its zero value. Optional booleans are pointers so that
+// they can be explicitly disabled; see Bool.
+type Network struct {
+	Match     Match
+	Link      NetworkLink
+	Network   NetworkSection
+	Addresses []Address
+	Routes    []Route
+	DHCPv4    DHCPv4
+	DHCPv6    DHCPv6
+
+	// Extra contains any additional sections, which are appended verbatim.
+	Extra []Section
+}
+
+// A Match is the [Match] section of a configuration file, which determines the
+// links to which the file applies.
+type Match struct {
+	// OriginalName applies only to .link files, which match on the kernel's
+	// name for a link rather than Name.
+	OriginalName        []string
+	Name                []string
+	MACAddress          []net.HardwareAddr
+	PermanentMACAddress []net.HardwareAddr
+	Path                []string
+	Driver              []string
+	Type                []string
+	Kind                []string
+	Property            []string
+
+	// Conditions on the host on which the configuration is applied.
+	Host              string
+	Virtualization    string
+	KernelCommandLine string
+	Architecture      string
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A NetworkLink is the [Link] section of a .network file.
+type NetworkLink struct {
+	MACAddress        net.HardwareAddr
+	MTUBytes          int
+	ARP               *bool
+	Multicast         *bool
+	AllMulticast      *bool
+	Unmanaged         *bool
+	RequiredForOnline string
+	ActivationPolicy  string
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A NetworkSection is the [Network] section of a .network file.
+type NetworkSection struct {
+	Description             string
+	DHCP                    string
+	DHCPServer              *bool
+	LinkLocalAddressing     string
+	IPv6AcceptRA            *bool
+	IPv6PrivacyExtensions   string
+	IPMasquerade            string
+	LLDP                    string
+	EmitLLDP                string
+	ConfigureWithoutCarrier *bool
+	IgnoreCarrierLoss       string
+	Address                 []netip.Prefix
+	Gateway                 []netip.Addr
+	DNS                     []string
+	Domains                 []string
+	NTP                     []string
+	Bridge                  string
+	Bond                    string
+	VLAN                    []string
+	MACVLAN                 []string
+	VXLAN                   []string
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// An Address is an [Address] section of a .network file.
+type Address struct {
+	Address           netip.Prefix
+	Peer              netip.Prefix
+	Broadcast         netip.Addr
+	Label             string
+	PreferredLifetime string
+	Scope             string
+	RouteMetric       uint32
+	AddPrefixRoute    *bool
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A Route is a [Route] section of a .network file.
+type Route struct {
+	Destination     netip.Prefix
+	Gateway         netip.Addr
+	GatewayOnLink   *bool
+	Source          netip.Prefix
+	PreferredSource netip.Addr
+	Metric          uint32
+	Table           string
+	Scope           string
+	Type            string
+	Protocol        string
+	MTUBytes        int
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A DHCPv4 is the [DHCPv4] section of a .network file.
+type DHCPv4 struct {
+	ClientIdentifier      string
+	VendorClassIdentifier string
+	Hostname              string
+	SendHostname          *bool
+	UseDNS                *bool
+	UseNTP                *bool
+	UseDomains            string
+	UseRoutes             *bool
+	UseGateway            *bool
+	UseMTU                *bool
+	UseHostname           *bool
+	RouteMetric           uint32
+	RouteTable            uint32
+	RequestOptions        []uint8
+	MaxAttempts           string
+	FallbackLeaseLifetime time.Duration
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// A DHCPv6 is the [DHCPv6] section of a .network file.
+type DHCPv6 struct {
+	UseAddress           *bool
+	UseDelegatedPrefix   *bool
+	UseDNS               *bool
+	UseNTP               *bool
+	UseDomains           string
+	WithoutRA            string
+	PrefixDelegationHint netip.Prefix
+	DUIDType             string
+	IAID                 uint32
+
+	// Extra contains any additional options, which are appended verbatim.
+	Extra []Option
+}
+
+// File produces the File representation of n.
+func (n *Network) File() (*File, error) {
+	var e encoder
+	e.section("Match", n.Match)
+	e.section("Link", n.Link)
+	e.section("Network", n.Network)
+	for _, a := range n.Addresses {
+		e.section("Address", a)
+	}
+	for _, r := range n.Routes {
+		e.section("Route", r)
+	}
+	e.section("DHCPv4", n.DHCPv4)
+	e.section("DHCPv6", n.DHCPv6)
+
+	return e.file(n.Extra)
+}
+
+// MarshalText implements encoding.TextMarshaler, rendering n as a .network
+// file.
+func (n *Network) MarshalText() ([]byte, error) {
+	f, err := n.File()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing a .network file
+// into n. Unknown sections and options, and options with values which cannot
+// be represented by n's fields, are preserved in the Extra fields.
+func (n *Network) UnmarshalText(b []byte) error {
+	f, err := ParseFile(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	n.decode(f)
+	return nil
+}
+
+// decode decodes the sections of f into n.
+func (n *Network) decode(f *File) {
+	*n = Network{}
+	for _, s := range f.Sections {
+		switch s.Name {
+		case "Match":
+			decodeSection(s, &n.Match)
+		case "Link":
+			decodeSection(s, &n.Link)
+		case "Network":
+			decodeSection(s, &n.Network)
+		case "Address":
+			var a Address
+			decodeSection(s, &a)
+			n.Addresses = append(n.Addresses, a)
+		case "Route":
+			var r Route
+			decodeSection(s, &r)
+			n.Routes = append(n.Routes, r)
+		case "DHCPv4":
+			decodeSection(s, &n.DHCPv4)
+		case "DHCPv6":
+			decodeSection(s, &n.DHCPv6)
+		default:
+			n.Extra = append(n.Extra, s)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNetworkMarshalText(t *testing.T) {
+	tests := []struct {
+		name string
+		n    Network
+		s    string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "DHCP",
+			n: Network{
+				Match:   Match{Name: []string{"eth0"}},
+				Network: NetworkSection{DHCP: "yes"},
+			},
+			s: `
+[Match]
+Name=eth0
+
+[Network]
+DHCP=yes
+`,
+		},
+		{
+			name: "full",
+			n: Network{
+				Match: Match{
+					MACAddress: []net.HardwareAddr{{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}},
+					Driver:     []string{"e1000e", "igb"},
+				},
+				Link: NetworkLink{
+					MTUBytes:          9000,
+					RequiredForOnline: "routable",
+				},
+				Network: NetworkSection{
+					Description:  "uplink",
+					IPv6AcceptRA: Bool(false),
+					DNS:          []string{"192.0.2.53", "2001:db8::53"},
+					VLAN:         []string{"vlan10"},
+					Extra:        []Option{{Key: "KeepConfiguration", Value: "static"}},
+				},
+				Addresses: []Address{
+					{Address: netip.MustParsePrefix("192.0.2.10/24")},
+					{
+						Address:           netip.MustParsePrefix("2001:db8::10/64"),
+						PreferredLifetime: "0",
+					},
+				},
+				Routes: []Route{{
+					Gateway:       netip.MustParseAddr("192.0.2.1"),
+					GatewayOnLink: Bool(true),
+					Metric:        100,
+				}},
+				DHCPv4: DHCPv4{
+					UseDNS:                Bool(true),
+					RequestOptions:        []uint8{42, 119},
+					FallbackLeaseLifetime: 90 * time.Minute,
+				},
+				Extra: []Section{{
+					Name:    "IPv6AcceptRA",
+					Options: []Option{{Key: "UseDNS", Value: "no"}},
+				}},
+			},
+			s: `
+[Match]
+MACAddress=00:11:22:33:44:55
+Driver=e1000e
+Driver=igb
+
+[Link]
+MTUBytes=9000
+RequiredForOnline=routable
+
+[Network]
+Description=uplink
+IPv6AcceptRA=no
+DNS=192.0.2.53
+DNS=2001:db8::53
+VLAN=vlan10
+KeepConfiguration=static
+
+[Address]
+Address=192.0.2.10/24
+
+[Address]
+Address=2001:db8::10/64
+PreferredLifetime=0
+
+[Route]
+Gateway=192.0.2.1
+GatewayOnLink=yes
+Metric=100
+
+[DHCPv4]
+UseDNS=yes
+RequestOptions=42
+RequestOptions=119
+FallbackLeaseLifetime=5400s
+
+[IPv6AcceptRA]
+UseDNS=no
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.n.MarshalText()
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+
+			if diff := cmp.Diff(strings.TrimPrefix(tt.s, "\n"), string(b)); diff != "" {
+				t.Fatalf("unexpected file (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNetworkMarshalTextControlCharacters(t *testing.T) {
+	tests := []struct {
+		name string
+		n    Network
+	}{
+		{
+			name: "value",
+			n: Network{
+				Network: NetworkSection{Description: "eth0\nDHCP=yes"},
+			},
+		},
+		{
+			name: "extra value",
+			n: Network{
+				Network: NetworkSection{Extra: []Option{{Key: "IPForward", Value: "yes\r"}}},
+			},
+		},
+		{
+			name: "extra key",
+			n: Network{
+				Network: NetworkSection{Extra: []Option{{Key: "DHCP=yes\nIPForward", Value: "yes"}}},
+			},
+		},
+		{
+			name: "extra section",
+			n: Network{
+				Extra: []Section{{
+					Name:    "Bridge",
+					Options: []Option{{Key: "Cost", Value: "10\x00"}},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.n.MarshalText(); err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+
+	f := &File{Sections: []Section{{
+		Name:    "Match\n[Network]",
+		Options: []Option{{Key: "Name", Value: "eth0"}},
+	}}}
+
+	if _, err := f.MarshalText(); err == nil {
+		t.Fatal("expected an error for section name, but none occurred")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d time.Duration
+		s string
+	}{
+		{d: 0, s: "0s"},
+		{d: 2 * time.Second, s: "2s"},
+		{d: 1500 * time.Millisecond, s: "1500ms"},
+		{d: 10 * time.Microsecond, s: "10us"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if diff := cmp.Diff(tt.s, formatDuration(tt.d)); diff != "" {
+				t.Fatalf("unexpected duration (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
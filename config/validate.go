@@ -0,0 +1,211 @@
+package config
+
+import "fmt"
+
+// An UnsupportedOption is an option or section which is not supported by a
+// version of systemd, and which networkd will ignore.
+type UnsupportedOption struct {
+	// Section is the name of the section. Key is the option's key, or empty
+	// if the section itself is unsupported.
+	Section, Key string
+
+	// Since is the first version of systemd which supports the option.
+	Since int
+}
+
+// String returns a description of the unsupported option.
+func (o UnsupportedOption) String() string {
+	if o.Key == "" {
+		return fmt.Sprintf("[%s] requires systemd %d", o.Section, o.Since)
+	}
+
+	return fmt.Sprintf("[%s] %s requires systemd %d", o.Section, o.Key, o.Since)
+}
+
+// Validate reports the options set in u which are not supported by the input
+// major version of systemd, and which networkd or udev would ignore. The
+// version of the running systemd can be detected using
+// networkd.Client.SystemdVersion.
+//
+// Only the sections and options with typed fields in this package are checked.
+// Any others, such as those set using Extra fields, are assumed to be supported.
+func Validate(u Unit, version int) ([]UnsupportedOption, error) {
+	f, err := u.File()
+	if err != nil {
+		return nil, err
+	}
+
+	var t versionTable
+	switch u.(type) {
+	case *Network:
+		t = networkVersions
+	case *NetDev:
+		t = netdevVersions
+	case *Link:
+		t = linkVersions
+	}
+
+	var (
+		out  []UnsupportedOption
+		seen = make(map[UnsupportedOption]bool)
+	)
+
+	// Report each option once, even if it is repeated.
+	add := func(o UnsupportedOption) {
+		if !seen[o] {
+			seen[o] = true
+			out = append(out, o)
+		}
+	}
+
+	for _, s := range f.Sections {
+		if len(s.Options) == 0 {
+			continue
+		}
+
+		// Sections are tracked by their key-less entry.
+		if since := t[s.Name][""]; since > version {
+			add(UnsupportedOption{Section: s.Name, Since: since})
+			continue
+		}
+
+		for _, o := range s.Options {
+			if since := t[s.Name][o.Key]; since > version {
+				add(UnsupportedOption{Section: s.Name, Key: o.Key, Since: since})
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// A versionTable maps section names and option keys to the first version of
+// systemd which supports them. The empty key refers to the section itself.
+// Sections and options which are supported by all versions of systemd with
+// networkd are omitted.
+type versionTable map[string]map[string]int
+
+// Versions are taken from the "Added in version" annotations of the
+// systemd.network(5), systemd.netdev(5), and systemd.link(5) manual pages.
+var (
+	matchVersions = map[string]int{
+		"PermanentMACAddress": 245,
+		"Property":            243,
+		"Kind":                251,
+	}
+
+	networkVersions = versionTable{
+		"Match": matchVersions,
+		"Link": {
+			"ARP":               232,
+			"Multicast":         239,
+			"AllMulticast":      239,
+			"Unmanaged":         233,
+			"RequiredForOnline": 236,
+			"ActivationPolicy":  248,
+		},
+		"Network": {
+			"DHCPServer":              215,
+			"LinkLocalAddressing":     219,
+			"IPv6AcceptRA":            231,
+			"IPv6PrivacyExtensions":   222,
+			"IPMasquerade":            219,
+			"LLDP":                    219,
+			"EmitLLDP":                230,
+			"ConfigureWithoutCarrier": 235,
+			"IgnoreCarrierLoss":       242,
+		},
+		"Address": {
+			"PreferredLifetime": 230,
+			"Scope":             235,
+			"RouteMetric":       246,
+			"AddPrefixRoute":    245,
+		},
+		"Route": {
+			"GatewayOnLink":   234,
+			"PreferredSource": 227,
+			"Table":           230,
+			"Scope":           219,
+			"Type":            235,
+			"Protocol":        234,
+			"MTUBytes":        239,
+		},
+		"DHCPv4": {
+			// Previously the [DHCP] section.
+			"":                      244,
+			"RequestOptions":        246,
+			"UseGateway":            246,
+			"MaxAttempts":           243,
+			"FallbackLeaseLifetime": 246,
+		},
+		"DHCPv6": {
+			"":                     243,
+			"UseDomains":           247,
+			"UseAddress":           248,
+			"UseDelegatedPrefix":   250,
+			"WithoutRA":            246,
+			"PrefixDelegationHint": 244,
+			"DUIDType":             244,
+			"IAID":                 244,
+		},
+	}
+
+	netdevVersions = versionTable{
+		"Match": matchVersions,
+		"Bridge": {
+			"HelloTimeSec":      227,
+			"MaxAgeSec":         227,
+			"ForwardDelaySec":   227,
+			"AgeingTimeSec":     232,
+			"Priority":          232,
+			"DefaultPVID":       232,
+			"VLANFiltering":     231,
+			"STP":               232,
+			"MulticastSnooping": 230,
+		},
+		"Bond": {
+			"AdSelect":              220,
+			"ARPIntervalSec":        220,
+			"ARPIPTargets":          220,
+			"PrimaryReselectPolicy": 220,
+			"MinLinks":              220,
+		},
+		"VLAN": {
+			"Protocol":      248,
+			"GVRP":          234,
+			"MVRP":          234,
+			"LooseBinding":  234,
+			"ReorderHeader": 234,
+		},
+		"VXLAN": {
+			"VNI":             243,
+			"Remote":          233,
+			"DestinationPort": 229,
+			"PortRange":       229,
+			"Independent":     243,
+		},
+	}
+
+	linkVersions = versionTable{
+		"Match": matchVersions,
+		"Link": {
+			"AlternativeNamesPolicy":     245,
+			"AlternativeName":            245,
+			"AutoNegotiation":            233,
+			"Port":                       234,
+			"ReceiveChecksumOffload":     245,
+			"TransmitChecksumOffload":    245,
+			"TCPSegmentationOffload":     232,
+			"TCP6SegmentationOffload":    232,
+			"GenericSegmentationOffload": 232,
+			"GenericReceiveOffload":      232,
+			"LargeReceiveOffload":        232,
+			"RxChannels":                 239,
+			"TxChannels":                 239,
+			"OtherChannels":              239,
+			"CombinedChannels":           239,
+			"RxBufferSize":               244,
+			"TxBufferSize":               244,
+		},
+	}
+)
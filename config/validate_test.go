@@ -0,0 +1,98 @@
+package config
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate(t *testing.T) {
+	n := &Network{
+		Match: Match{Name: []string{"eth0"}, Kind: []string{"veth"}},
+		Link:  NetworkLink{RequiredForOnline: "routable"},
+		Network: NetworkSection{
+			DHCP:  "yes",
+			Extra: []Option{{Key: "KeepConfiguration", Value: "yes"}},
+		},
+		Addresses: []Address{
+			{Address: netip.MustParsePrefix("192.0.2.10/24"), RouteMetric: 10},
+			{Address: netip.MustParsePrefix("192.0.2.11/24"), RouteMetric: 10},
+		},
+		DHCPv6: DHCPv6{UseDNS: Bool(false)},
+	}
+
+	tests := []struct {
+		name    string
+		u       Unit
+		version int
+		want    []UnsupportedOption
+	}{
+		{
+			name:    "network supported",
+			u:       n,
+			version: 255,
+		},
+		{
+			name:    "network old",
+			u:       n,
+			version: 242,
+			want: []UnsupportedOption{
+				{Section: "Match", Key: "Kind", Since: 251},
+				{Section: "Address", Key: "RouteMetric", Since: 246},
+				{Section: "DHCPv6", Since: 243},
+			},
+		},
+		{
+			name: "netdev",
+			u: &NetDev{
+				NetDev: NetDevSection{Name: "vlan10"},
+				Kind:   &VLAN{ID: 10, Protocol: "802.1ad"},
+			},
+			version: 247,
+			want:    []UnsupportedOption{{Section: "VLAN", Key: "Protocol", Since: 248}},
+		},
+		{
+			name: "link",
+			u: &Link{
+				Match: Match{OriginalName: []string{"*"}},
+				Link:  LinkSection{RxBufferSize: 4096},
+			},
+			version: 240,
+			want:    []UnsupportedOption{{Section: "Link", Key: "RxBufferSize", Since: 244}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Validate(tt.u, tt.version)
+			if err != nil {
+				t.Fatalf("failed to validate: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected unsupported options (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnsupportedOptionString(t *testing.T) {
+	opts := []UnsupportedOption{
+		{Section: "DHCPv6", Since: 243},
+		{Section: "Link", Key: "RxBufferSize", Since: 244},
+	}
+
+	var got []string
+	for _, o := range opts {
+		got = append(got, o.String())
+	}
+
+	want := []string{
+		"[DHCPv6] requires systemd 243",
+		"[Link] RxBufferSize requires systemd 244",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected strings (-want +got):\n%s", diff)
+	}
+}
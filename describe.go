@@ -0,0 +1,792 @@
+package networkd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// A LinkDescription is the typed form of networkd's JSON description of a
+// link, as returned by LinkService.Describe.
+type LinkDescription struct {
+	Index            int
+	Name             string
+	AlternativeNames []string
+
+	// Type is the link's type as reported by networkd, such as "ether",
+	// "loopback", or "wlan".
+	Type string
+
+	// Flags are the link's kernel IFF_* flags, and FlagNames are the names of
+	// each of those flags as reported by networkd, such as "up" and
+	// "lower-up".
+	Flags     uint32
+	FlagNames []string
+
+	// KernelOperationalState is the kernel's RFC 2863 operational state for
+	// the link, such as "up" or "dormant".
+	KernelOperationalState string
+
+	// MTU is the link's current MTU, and MinimumMTU and MaximumMTU are the
+	// bounds supported by the device. Each is zero if unknown.
+	MTU, MinimumMTU, MaximumMTU int
+
+	// The link's networkd state machine values, as also exposed by
+	// LinkProperties.
+	AdministrativeState AdministrativeState
+	OperationalState    OperationalState
+	CarrierState        CarrierState
+	AddressState        AddressState
+	IPv4AddressState    AddressState
+	IPv6AddressState    AddressState
+	OnlineState         OnlineState
+
+	// ActivationPolicy is the link's configured ActivationPolicy, such as
+	// "up" or "manual".
+	ActivationPolicy string
+
+	// HardwareAddress is the link's current hardware address, and
+	// PermanentHardwareAddress is the hardware address assigned to the
+	// device by its manufacturer. Either may be nil if not applicable.
+	HardwareAddress          net.HardwareAddr
+	PermanentHardwareAddress net.HardwareAddr
+
+	// BroadcastAddress is the link-layer broadcast address, if any.
+	BroadcastAddress net.HardwareAddr
+
+	// IPv6LinkLocalAddress is the link's IPv6 link-local address, if any.
+	IPv6LinkLocalAddress netip.Addr
+
+	// Driver is the name of the kernel driver backing the link, if known.
+	Driver string
+
+	// NetworkFile is the path to the .network file which configured the
+	// link, and NetworkFileDropins are the paths to any of its drop-in
+	// files. LinkFile is the path to the .link file which udev applied to
+	// the link. Each is empty if no such file applies.
+	NetworkFile        string
+	NetworkFileDropins []string
+	LinkFile           string
+
+	// RequiredForOnline reports whether the link must be online for the
+	// system to be considered online.
+	RequiredForOnline bool
+
+	// RequiredOperationalStateForOnline is the range of operational states
+	// in which the link is considered online.
+	RequiredOperationalStateForOnline OperationalStateRange
+
+	// RequiredFamilyForOnline is the address family which the link must have
+	// configured to be considered online.
+	RequiredFamilyForOnline AddressFamily
+
+	// LLDPNeighbors are the LLDP neighbors discovered on the link.
+	LLDPNeighbors []LLDPNeighbor
+
+	// Addresses are the IP addresses configured on the link.
+	Addresses []Address
+
+	// Routes are the routes which use the link.
+	Routes []Route
+
+	// NextHops are the next hop objects configured on the link, and
+	// Neighbors are its static ARP and NDP neighbor entries.
+	NextHops  []NextHop
+	Neighbors []Neighbor
+
+	// DNS and NTP are the link's DNS and NTP servers, and Domains are its
+	// search and routing-only DNS domains.
+	DNS     []DNSServer
+	NTP     []NTPServer
+	Domains []Domain
+
+	// DHCPv4 is the link's current DHCPv4 lease, or nil if the link has no
+	// DHCPv4 lease.
+	DHCPv4 *DHCPLease
+
+	// DHCPv6 is the link's current DHCPv6 lease, or nil if the link has no
+	// DHCPv6 lease.
+	DHCPv6 *DHCPv6Lease
+
+	// DHCPServerLeases are the dynamic and static leases of the link's DHCP
+	// server, if it runs one.
+	DHCPServerLeases []DHCPServerLease
+
+	// Raw contains networkd's unprocessed JSON description of the link,
+	// including any fields which are not yet exposed by this package.
+	Raw json.RawMessage
+}
+
+// An Address is an IP address configured on a link.
+type Address struct {
+	// Prefix is the address and its subnet prefix length.
+	Prefix netip.Prefix
+
+	// Broadcast is the IPv4 broadcast address for the subnet, if any.
+	Broadcast netip.Addr
+
+	// Scope is the name of the address's scope, such as "global", "link", or
+	// "host".
+	Scope string
+
+	// Flags are the address's kernel IFA_F_* flags, and FlagNames are the
+	// names of each of those flags as reported by networkd, such as
+	// "permanent" and "noprefixroute".
+	Flags     uint32
+	FlagNames []string
+
+	// Label is the address's IPv4 label, if any.
+	Label string
+
+	// PreferredLifetime and ValidLifetime are the times at which the address
+	// is deprecated and removed. Each is the zero time.Time if the lifetime
+	// is infinite.
+	PreferredLifetime, ValidLifetime time.Time
+
+	// ConfigSource is the source of the address's configuration, and
+	// ConfigProvider is the address of the entity which provided it, such as
+	// a DHCP server or IPv6 router, if any.
+	ConfigSource   ConfigSource
+	ConfigProvider netip.Addr
+}
+
+// A ConfigSource is the source of the configuration of an address, route, or
+// other object which networkd has installed or observed on a link.
+type ConfigSource string
+
+// Possible ConfigSource values.
+const (
+	// ConfigForeign indicates that the object was not configured by
+	// networkd, such as by the kernel or another program.
+	ConfigForeign ConfigSource = "foreign"
+
+	// ConfigStatic indicates that the object was configured by a .network
+	// file.
+	ConfigStatic ConfigSource = "static"
+
+	// ConfigIPv4LL indicates that the object was configured by IPv4
+	// link-local addressing.
+	ConfigIPv4LL ConfigSource = "IPv4LL"
+
+	// ConfigDHCPv4, ConfigDHCPv6, and ConfigDHCPPD indicate that the object
+	// was configured by DHCPv4, DHCPv6, or DHCPv6 prefix delegation.
+	ConfigDHCPv4 ConfigSource = "DHCPv4"
+	ConfigDHCPv6 ConfigSource = "DHCPv6"
+	ConfigDHCPPD ConfigSource = "DHCP-PD"
+
+	// ConfigNDisc indicates that the object was configured by IPv6 Neighbor
+	// Discovery, such as router advertisements.
+	ConfigNDisc ConfigSource = "NDisc"
+
+	// ConfigRuntime indicates that the object was configured at runtime,
+	// such as over D-Bus.
+	ConfigRuntime ConfigSource = "runtime"
+)
+
+// An OperationalStateRange is an inclusive range of OperationalStates.
+type OperationalStateRange struct {
+	Min, Max OperationalState
+}
+
+// Contains reports whether s is within r. An empty Min or Max leaves that end
+// of the range unbounded.
+func (r OperationalStateRange) Contains(s OperationalState) bool {
+	if r.Min != "" && !s.AtLeast(r.Min) {
+		return false
+	}
+
+	return r.Max == "" || r.Max.AtLeast(s)
+}
+
+// An AddressFamily specifies one or more IP address families.
+type AddressFamily string
+
+// Possible AddressFamily values.
+const (
+	FamilyAny  AddressFamily = "any"
+	FamilyIPv4 AddressFamily = "ipv4"
+	FamilyIPv6 AddressFamily = "ipv6"
+	FamilyBoth AddressFamily = "both"
+)
+
+// Describe fetches and decodes networkd's JSON description of this link. The
+// undecoded JSON is also available in the Raw field of the result, so it is not
+// necessary to call DescribeRaw as well.
+func (ls *LinkService) Describe(ctx context.Context) (*LinkDescription, error) {
+	clk, err := newBootClock()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ls.DescribeRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLinkDescription(clk, b)
+}
+
+//go:generate go run ./internal/describegen -o zdescribe.go testdata/*.json
+
+// A jsonLinkDescription is a JSON link description produced by networkd.
+// Unknown fields are ignored so that newer versions of networkd may add
+// fields, but the raw JSON is retained.
+type jsonLinkDescription struct {
+	jsonLink
+	raw json.RawMessage
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (jd *jsonLinkDescription) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &jd.jsonLink); err != nil {
+		return err
+	}
+
+	jd.raw = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// decodeLinkDescription decodes networkd's JSON description of a link.
+func decodeLinkDescription(b []byte) (*jsonLinkDescription, error) {
+	var jd jsonLinkDescription
+	if err := json.Unmarshal(b, &jd); err != nil {
+		return nil, fmt.Errorf("networkd: failed to decode link description: %w", err)
+	}
+
+	return &jd, nil
+}
+
+// parseLinkDescription parses a LinkDescription from networkd's JSON
+// description of a link.
+func parseLinkDescription(clk bootClock, b []byte) (*LinkDescription, error) {
+	jd, err := decodeLinkDescription(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return jd.description(clk), nil
+}
+
+// description converts a JSON link description to a LinkDescription, using clk
+// to convert timestamps.
+func (jd *jsonLinkDescription) description(clk bootClock) *LinkDescription {
+	d := &LinkDescription{
+		Index:                    jd.Index,
+		Name:                     jd.Name,
+		AlternativeNames:         jd.AlternativeNames,
+		Type:                     jd.Type,
+		Flags:                    jd.Flags,
+		KernelOperationalState:   jd.KernelOperationalStateString,
+		MTU:                      jd.MTU,
+		MinimumMTU:               jd.MinimumMTU,
+		MaximumMTU:               jd.MaximumMTU,
+		AdministrativeState:      jd.AdministrativeState,
+		OperationalState:         jd.OperationalState,
+		CarrierState:             jd.CarrierState,
+		AddressState:             jd.AddressState,
+		IPv4AddressState:         jd.IPv4AddressState,
+		IPv6AddressState:         jd.IPv6AddressState,
+		OnlineState:              jd.OnlineState,
+		ActivationPolicy:         jd.ActivationPolicy,
+		HardwareAddress:          net.HardwareAddr(jd.HardwareAddress),
+		PermanentHardwareAddress: net.HardwareAddr(jd.PermanentHardwareAddress),
+		BroadcastAddress:         net.HardwareAddr(jd.BroadcastAddress),
+		IPv6LinkLocalAddress:     netip.Addr(jd.IPv6LinkLocalAddress),
+		Driver:                   jd.Driver,
+		NetworkFile:              jd.NetworkFile,
+		NetworkFileDropins:       jd.NetworkFileDropins,
+		LinkFile:                 jd.LinkFile,
+		RequiredForOnline:        jd.RequiredForOnline,
+		RequiredFamilyForOnline:  jd.RequiredFamilyForOnline,
+		LLDPNeighbors:            toLLDPNeighbors(jd.LLDP),
+		FlagNames:                splitFlags(jd.FlagsString),
+		Addresses:                toAddresses(clk, jd.Addresses),
+		Routes:                   toRoutes(clk, jd.Routes),
+		NextHops:                 toNextHops(jd.NextHops),
+		Neighbors:                toNeighbors(jd.Neighbors),
+		DNS:                      toDNSServers(jd.DNS),
+		NTP:                      toNTPServers(jd.NTP),
+		Domains:                  toDomains(jd.SearchDomains, jd.RouteDomains),
+		DHCPv4:                   jd.dhcpLease(clk),
+		DHCPv6:                   jd.dhcpv6Lease(clk),
+		DHCPServerLeases:         jd.dhcpServerLeases(clk),
+		Raw:                      jd.raw,
+	}
+
+	// networkd emits the required operational state as a [min, max] pair.
+	if rs := jd.RequiredOperationalStateForOnline; len(rs) == 2 {
+		d.RequiredOperationalStateForOnline = OperationalStateRange{Min: rs[0], Max: rs[1]}
+	}
+
+	return d
+}
+
+// A Route is a route which uses a link.
+type Route struct {
+	// Destination is the route's destination prefix, such as 0.0.0.0/0 for
+	// an IPv4 default route.
+	Destination netip.Prefix
+
+	// Gateway is the next hop for the route, if any. PreferredSource is the
+	// route's preferred source address, if any.
+	Gateway         netip.Addr
+	PreferredSource netip.Addr
+
+	// Table is the routing table which contains the route, and Metric is its
+	// priority within that table.
+	Table  uint32
+	Metric uint32
+
+	// Protocol, Scope, and Type are the names of the route's routing
+	// protocol, scope, and type, such as "dhcp", "global", and "unicast".
+	Protocol string
+	Scope    string
+	Type     string
+
+	// MTU is the route's MTU, or zero if unset.
+	MTU int
+
+	// MultiPath contains the next hops for a multipath route.
+	MultiPath []RouteNextHop
+
+	// Expires is the time at which the route expires, or the zero time.Time
+	// if the route does not expire.
+	Expires time.Time
+
+	// ConfigSource is the source of the route's configuration, and
+	// ConfigProvider is the address of the entity which provided it, if any.
+	ConfigSource   ConfigSource
+	ConfigProvider netip.Addr
+}
+
+// A RouteNextHop is one of the next hops of a multipath Route.
+type RouteNextHop struct {
+	Gateway netip.Addr
+
+	// Index is the interface index of the link used by the next hop.
+	Index int
+
+	// Weight is the relative weight of the next hop.
+	Weight uint32
+}
+
+// A NextHop is a kernel next hop object, as configured by the [NextHop]
+// section of a .network file.
+type NextHop struct {
+	// ID is the next hop's identifier.
+	ID uint32
+
+	// Gateway is the next hop's gateway address, if any.
+	Gateway netip.Addr
+
+	// Blackhole reports whether packets using the next hop are discarded.
+	Blackhole bool
+
+	// Group contains the members of a next hop group. If empty, the next hop
+	// is not a group.
+	Group []NextHopGroupMember
+
+	// Protocol is the name of the routing protocol which installed the next
+	// hop, such as "static".
+	Protocol string
+
+	// ConfigSource is the source of the next hop's configuration.
+	ConfigSource ConfigSource
+}
+
+// A NextHopGroupMember is a member of a next hop group.
+type NextHopGroupMember struct {
+	// ID is the identifier of the member NextHop, and Weight is its relative
+	// weight within the group.
+	ID     uint32
+	Weight uint32
+}
+
+// A Neighbor is a static neighbor table entry, as configured by the [Neighbor]
+// section of a .network file.
+type Neighbor struct {
+	// Address is the IP address of the neighbor, and LinkLayerAddress is its
+	// link-layer address.
+	Address          netip.Addr
+	LinkLayerAddress net.HardwareAddr
+
+	// ConfigSource is the source of the neighbor's configuration.
+	ConfigSource ConfigSource
+}
+
+// toAddresses converts JSON addresses to Addresses.
+func toAddresses(clk bootClock, jas []jsonAddress) []Address {
+	if len(jas) == 0 {
+		return nil
+	}
+
+	as := make([]Address, 0, len(jas))
+	for _, ja := range jas {
+		as = append(as, Address{
+			Prefix:            netip.PrefixFrom(netip.Addr(ja.Address), ja.PrefixLength),
+			Broadcast:         netip.Addr(ja.Broadcast),
+			Scope:             ja.ScopeString,
+			Flags:             ja.Flags,
+			FlagNames:         splitFlags(ja.FlagsString),
+			Label:             ja.Label,
+			PreferredLifetime: clk.Time(ja.PreferredLifetimeUSec),
+			ValidLifetime:     clk.Time(ja.ValidLifetimeUSec),
+			ConfigSource:      ja.ConfigSource,
+			ConfigProvider:    netip.Addr(ja.ConfigProvider),
+		})
+	}
+
+	return as
+}
+
+// toRoutes converts JSON routes to Routes.
+func toRoutes(clk bootClock, jrs []jsonRoute) []Route {
+	if len(jrs) == 0 {
+		return nil
+	}
+
+	rs := make([]Route, 0, len(jrs))
+	for _, jr := range jrs {
+		r := Route{
+			Destination:     netip.PrefixFrom(netip.Addr(jr.Destination), jr.DestinationPrefixLength),
+			Gateway:         netip.Addr(jr.Gateway),
+			PreferredSource: netip.Addr(jr.PreferredSource),
+			Table:           jr.Table,
+			Metric:          jr.Priority,
+			Protocol:        jr.ProtocolString,
+			Scope:           jr.ScopeString,
+			Type:            jr.TypeString,
+			MTU:             jr.MTU,
+			Expires:         clk.Time(jr.LifetimeUSec),
+			ConfigSource:    jr.ConfigSource,
+			ConfigProvider:  netip.Addr(jr.ConfigProvider),
+		}
+
+		for _, mp := range jr.MultiPathRoutes {
+			r.MultiPath = append(r.MultiPath, RouteNextHop{
+				Gateway: netip.Addr(mp.Gateway),
+				Index:   mp.Ifindex,
+				Weight:  mp.Weight,
+			})
+		}
+
+		rs = append(rs, r)
+	}
+
+	return rs
+}
+
+// toNextHops converts JSON next hops to NextHops.
+func toNextHops(jns []jsonNextHop) []NextHop {
+	if len(jns) == 0 {
+		return nil
+	}
+
+	ns := make([]NextHop, 0, len(jns))
+	for _, jn := range jns {
+		n := NextHop{
+			ID:           jn.ID,
+			Gateway:      netip.Addr(jn.Gateway),
+			Blackhole:    jn.Blackhole,
+			Protocol:     jn.ProtocolString,
+			ConfigSource: jn.ConfigSource,
+		}
+
+		for _, g := range jn.Group {
+			n.Group = append(n.Group, NextHopGroupMember(g))
+		}
+
+		ns = append(ns, n)
+	}
+
+	return ns
+}
+
+// toNeighbors converts JSON neighbors to Neighbors.
+func toNeighbors(jns []jsonNeighbor) []Neighbor {
+	if len(jns) == 0 {
+		return nil
+	}
+
+	ns := make([]Neighbor, 0, len(jns))
+	for _, jn := range jns {
+		ns = append(ns, Neighbor{
+			Address:          netip.Addr(jn.Destination),
+			LinkLayerAddress: net.HardwareAddr(jn.LinkLayerAddress),
+			ConfigSource:     jn.ConfigSource,
+		})
+	}
+
+	return ns
+}
+
+// splitFlags splits networkd's comma-separated flags strings into the names of
+// each flag.
+func splitFlags(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+// A NetworkDescription is the typed form of networkd's JSON description of its
+// global state, as returned by ManagerService.Describe.
+type NetworkDescription struct {
+	// The system-wide networkd state machine values, as also exposed by
+	// ManagerProperties.
+	OperationalState OperationalState
+	CarrierState     CarrierState
+	AddressState     AddressState
+	IPv4AddressState AddressState
+	IPv6AddressState AddressState
+	OnlineState      OnlineState
+
+	// NamespaceID is the inode number of the network namespace in which
+	// networkd is running, or zero if unknown.
+	NamespaceID uint64
+
+	// DNS and NTP are the global DNS and NTP servers, and Domains are the
+	// global search and routing-only DNS domains.
+	DNS     []DNSServer
+	NTP     []NTPServer
+	Domains []Domain
+
+	// RoutingPolicyRules are the routing policy rules known to networkd.
+	RoutingPolicyRules []RoutingPolicyRule
+
+	// Links are the descriptions of each link known to networkd.
+	Links []LinkDescription
+
+	// Raw contains networkd's unprocessed JSON description, including any
+	// fields which are not yet exposed by this package.
+	Raw json.RawMessage
+}
+
+// A RoutingPolicyRule is a routing policy rule, as configured by the
+// [RoutingPolicyRule] section of a .network file.
+type RoutingPolicyRule struct {
+	// Priority is the priority of the rule. Rules are evaluated in order of
+	// increasing priority.
+	Priority uint32
+
+	// From and To are the source and destination prefixes matched by the
+	// rule. Each is the zero value if the rule matches any address.
+	From, To netip.Prefix
+
+	// FirewallMark and FirewallMask are the firewall mark and mask matched by
+	// the rule. Each is zero if unset.
+	FirewallMark, FirewallMask uint32
+
+	// IncomingInterface and OutgoingInterface are the names of the links
+	// matched by the rule, if any.
+	IncomingInterface, OutgoingInterface string
+
+	// Invert reports whether the rule's match is inverted.
+	Invert bool
+
+	// Table is the routing table consulted when the rule matches, and Type is
+	// the name of the rule's action, such as "table" or "blackhole".
+	Table uint32
+	Type  string
+
+	// Protocol is the name of the routing protocol which installed the rule,
+	// such as "static" or "kernel".
+	Protocol string
+
+	// ConfigSource is the source of the rule's configuration.
+	ConfigSource ConfigSource
+}
+
+// An NTPServer is an NTP server specified by either IP address or host name.
+type NTPServer struct {
+	// Addr is the IP address of the server. If the zero value, the server is
+	// specified by Name.
+	Addr netip.Addr
+
+	// Name is the host name of the server, if the server is not specified by
+	// IP address.
+	Name string
+}
+
+// Describe fetches and decodes networkd's JSON description of its global
+// state and of all links it knows about. The undecoded JSON is also available
+// in the Raw field of the result, so it is not necessary to call DescribeRaw as
+// well.
+func (ms *ManagerService) Describe(ctx context.Context) (*NetworkDescription, error) {
+	clk, err := newBootClock()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ms.DescribeRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNetworkDescription(clk, b)
+}
+
+// decodeNetworkDescription decodes networkd's JSON description of its global
+// state.
+func decodeNetworkDescription(b []byte) (*jsonNetworkDescription, error) {
+	var jd jsonNetworkDescription
+	if err := json.Unmarshal(b, &jd); err != nil {
+		return nil, fmt.Errorf("networkd: failed to decode description: %w", err)
+	}
+
+	return &jd, nil
+}
+
+// parseNetworkDescription parses a NetworkDescription from networkd's JSON
+// description of its global state.
+func parseNetworkDescription(clk bootClock, b []byte) (*NetworkDescription, error) {
+	jd, err := decodeNetworkDescription(b)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &NetworkDescription{
+		OperationalState: jd.OperationalState,
+		CarrierState:     jd.CarrierState,
+		AddressState:     jd.AddressState,
+		IPv4AddressState: jd.IPv4AddressState,
+		IPv6AddressState: jd.IPv6AddressState,
+		OnlineState:      jd.OnlineState,
+		NamespaceID:      jd.NamespaceID,
+		DNS:              toDNSServers(jd.DNS),
+		NTP:              toNTPServers(jd.NTP),
+		Domains:          toDomains(jd.SearchDomains, jd.RouteDomains),
+		Links:            make([]LinkDescription, 0, len(jd.Interfaces)),
+		Raw:              b,
+	}
+
+	for _, jr := range jd.RoutingPolicyRules {
+		d.RoutingPolicyRules = append(d.RoutingPolicyRules, RoutingPolicyRule{
+			Priority:          jr.Priority,
+			From:              jsonPrefix(jr.FromPrefix, jr.FromPrefixLength),
+			To:                jsonPrefix(jr.ToPrefix, jr.ToPrefixLength),
+			FirewallMark:      jr.FirewallMark,
+			FirewallMask:      jr.FirewallMask,
+			IncomingInterface: jr.IncomingInterface,
+			OutgoingInterface: jr.OutgoingInterface,
+			Invert:            jr.Invert,
+			Table:             jr.Table,
+			Type:              jr.TypeString,
+			Protocol:          jr.ProtocolString,
+			ConfigSource:      jr.ConfigSource,
+		})
+	}
+
+	for _, jl := range jd.Interfaces {
+		d.Links = append(d.Links, *jl.description(clk))
+	}
+
+	return d, nil
+}
+
+// toDNSServers converts JSON DNS servers to DNSServers.
+func toDNSServers(jds []jsonDNS) []DNSServer {
+	if len(jds) == 0 {
+		return nil
+	}
+
+	ds := make([]DNSServer, 0, len(jds))
+	for _, jd := range jds {
+		ds = append(ds, DNSServer{
+			Addr:       netip.Addr(jd.Address),
+			Port:       jd.Port,
+			ServerName: jd.ServerName,
+		})
+	}
+
+	return ds
+}
+
+// toNTPServers converts JSON NTP servers to NTPServers.
+func toNTPServers(jns []jsonNTP) []NTPServer {
+	if len(jns) == 0 {
+		return nil
+	}
+
+	ns := make([]NTPServer, 0, len(jns))
+	for _, jn := range jns {
+		ns = append(ns, NTPServer{
+			Addr: netip.Addr(jn.Address),
+			Name: jn.Server,
+		})
+	}
+
+	return ns
+}
+
+// toDomains converts JSON search and routing-only domains to Domains.
+func toDomains(search, route []jsonDomain) []Domain {
+	if len(search)+len(route) == 0 {
+		return nil
+	}
+
+	ds := make([]Domain, 0, len(search)+len(route))
+	for _, d := range search {
+		ds = append(ds, Domain{Name: d.Domain})
+	}
+	for _, d := range route {
+		ds = append(ds, Domain{Name: d.Domain, RoutingOnly: true})
+	}
+
+	return ds
+}
+
+// jsonPrefix produces a netip.Prefix from a JSON address and prefix length, or
+// the zero value if the address is unset.
+func jsonPrefix(addr jsonAddr, bits int) netip.Prefix {
+	ip := netip.Addr(addr)
+	if !ip.IsValid() {
+		return netip.Prefix{}
+	}
+
+	return netip.PrefixFrom(ip, bits)
+}
+
+// jsonBytes is a byte slice which networkd encodes as a JSON array of numbers,
+// rather than the base64 string expected by encoding/json.
+type jsonBytes []byte
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *jsonBytes) UnmarshalJSON(data []byte) error {
+	var ns []uint8
+	if err := json.Unmarshal(data, &ns); err != nil {
+		return err
+	}
+
+	*b = ns
+	return nil
+}
+
+// jsonAddr is an IP address which networkd encodes as a JSON array of numbers.
+// An empty or missing array produces the zero netip.Addr.
+type jsonAddr netip.Addr
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *jsonAddr) UnmarshalJSON(data []byte) error {
+	var b jsonBytes
+	if err := b.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		*a = jsonAddr{}
+		return nil
+	}
+
+	ip, ok := netip.AddrFromSlice(b)
+	if !ok {
+		return fmt.Errorf("networkd: invalid IP address: %v", []byte(b))
+	}
+
+	*a = jsonAddr(ip)
+	return nil
+}
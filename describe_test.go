@@ -0,0 +1,474 @@
+package networkd
+
+import (
+	"encoding/json"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseLinkDescription(t *testing.T) {
+	got := testLinkDescription(t, "link-eth0.json")
+
+	want := &LinkDescription{
+		Index:                    2,
+		Name:                     "eth0",
+		AlternativeNames:         []string{"enp0s3", "ens3"},
+		Type:                     "ether",
+		Flags:                    69699,
+		FlagNames:                []string{"up", "broadcast", "running", "multicast", "lower-up"},
+		KernelOperationalState:   "up",
+		MTU:                      1500,
+		MinimumMTU:               68,
+		MaximumMTU:               65535,
+		AdministrativeState:      AdministrativeConfigured,
+		OperationalState:         OperationalRoutable,
+		CarrierState:             CarrierCarrier,
+		AddressState:             AddressRoutable,
+		IPv4AddressState:         AddressRoutable,
+		IPv6AddressState:         AddressRoutable,
+		OnlineState:              OnlineOnline,
+		ActivationPolicy:         "up",
+		HardwareAddress:          net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x34, 0x56},
+		PermanentHardwareAddress: net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x34, 0x56},
+		BroadcastAddress:         net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		IPv6LinkLocalAddress:     netip.MustParseAddr("fe80::5054:ff:fe12:3456"),
+		Driver:                   "virtio_net",
+		NetworkFile:              "/etc/systemd/network/10-eth0.network",
+		NetworkFileDropins:       []string{"/etc/systemd/network/10-eth0.network.d/mtu.conf"},
+		LinkFile:                 "/usr/lib/systemd/network/99-default.link",
+		RequiredForOnline:        true,
+		RequiredOperationalStateForOnline: OperationalStateRange{
+			Min: OperationalDegraded,
+			Max: OperationalRoutable,
+		},
+		RequiredFamilyForOnline: FamilyIPv4,
+		LLDPNeighbors: []LLDPNeighbor{{
+			ChassisID:           "00:11:22:33:44:55",
+			RawChassisID:        []byte{4, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+			PortID:              "ge-0/0/1",
+			RawPortID:           []byte{5, 'g', 'e', '-', '0', '/', '0', '/', '1'},
+			PortDescription:     "uplink to server",
+			SystemName:          "switch01",
+			SystemDescription:   "Juniper Networks EX2300",
+			EnabledCapabilities: 20,
+			VLANID:              10,
+		}},
+		Addresses: []Address{
+			{
+				Prefix:            netip.MustParsePrefix("192.168.1.100/24"),
+				Broadcast:         netip.MustParseAddr("192.168.1.255"),
+				Scope:             "global",
+				PreferredLifetime: time.Unix(86500, 0),
+				ValidLifetime:     time.Unix(86500, 0),
+				ConfigSource:      ConfigDHCPv4,
+				ConfigProvider:    netip.MustParseAddr("192.168.1.1"),
+			},
+			{
+				Prefix:       netip.MustParsePrefix("10.0.0.5/8"),
+				Scope:        "global",
+				Flags:        128,
+				FlagNames:    []string{"permanent"},
+				Label:        "eth0:static",
+				ConfigSource: ConfigStatic,
+			},
+			{
+				Prefix:            netip.MustParsePrefix("2001:db8::5054:ff:fe12:3456/64"),
+				Scope:             "global",
+				Flags:             512,
+				FlagNames:         []string{"noprefixroute"},
+				PreferredLifetime: time.Unix(14500, 0),
+				ValidLifetime:     time.Unix(2592100, 0),
+				ConfigSource:      ConfigNDisc,
+				ConfigProvider:    netip.MustParseAddr("fe80::1"),
+			},
+			{
+				Prefix:            netip.MustParsePrefix("2001:db8:ffff::10/128"),
+				Scope:             "global",
+				Flags:             512,
+				FlagNames:         []string{"noprefixroute"},
+				PreferredLifetime: time.Unix(3800, 0),
+				ValidLifetime:     time.Unix(7400, 0),
+				ConfigSource:      ConfigDHCPv6,
+				ConfigProvider:    netip.MustParseAddr("fe80::2"),
+			},
+			{
+				Prefix:       netip.MustParsePrefix("fe80::5054:ff:fe12:3456/64"),
+				Scope:        "link",
+				Flags:        128,
+				FlagNames:    []string{"permanent"},
+				ConfigSource: ConfigForeign,
+			},
+		},
+		Routes: []Route{
+			{
+				Destination:     netip.MustParsePrefix("0.0.0.0/0"),
+				Gateway:         netip.MustParseAddr("192.168.1.1"),
+				PreferredSource: netip.MustParseAddr("192.168.1.100"),
+				Table:           254,
+				Metric:          1024,
+				Protocol:        "dhcp",
+				Scope:           "global",
+				Type:            "unicast",
+				Expires:         time.Unix(86500, 0),
+				ConfigSource:    ConfigDHCPv4,
+				ConfigProvider:  netip.MustParseAddr("192.168.1.1"),
+			},
+			{
+				Destination:     netip.MustParsePrefix("192.168.1.0/24"),
+				PreferredSource: netip.MustParseAddr("192.168.1.100"),
+				Table:           254,
+				Protocol:        "kernel",
+				Scope:           "link",
+				Type:            "unicast",
+				ConfigSource:    ConfigForeign,
+			},
+			{
+				Destination: netip.MustParsePrefix("203.0.113.0/24"),
+				Table:       100,
+				Protocol:    "static",
+				Scope:       "global",
+				Type:        "unicast",
+				MTU:         1400,
+				MultiPath: []RouteNextHop{
+					{Gateway: netip.MustParseAddr("192.168.1.2"), Index: 2, Weight: 1},
+					{Gateway: netip.MustParseAddr("192.168.1.3"), Index: 2, Weight: 2},
+				},
+				ConfigSource: ConfigStatic,
+			},
+			{
+				Destination:    netip.MustParsePrefix("::/0"),
+				Gateway:        netip.MustParseAddr("fe80::1"),
+				Table:          254,
+				Metric:         1024,
+				Protocol:       "ra",
+				Scope:          "global",
+				Type:           "unicast",
+				Expires:        time.Unix(1900, 0),
+				ConfigSource:   ConfigNDisc,
+				ConfigProvider: netip.MustParseAddr("fe80::1"),
+			},
+			{
+				Destination:    netip.MustParsePrefix("2001:db8::/64"),
+				Table:          254,
+				Metric:         256,
+				Protocol:       "ra",
+				Scope:          "global",
+				Type:           "unicast",
+				Expires:        time.Unix(2592100, 0),
+				ConfigSource:   ConfigNDisc,
+				ConfigProvider: netip.MustParseAddr("fe80::1"),
+			},
+		},
+		NextHops: []NextHop{
+			{ID: 1, Gateway: netip.MustParseAddr("192.168.1.2"), Protocol: "static", ConfigSource: ConfigStatic},
+			{ID: 2, Gateway: netip.MustParseAddr("192.168.1.3"), Protocol: "static", ConfigSource: ConfigStatic},
+			{
+				ID:           10,
+				Group:        []NextHopGroupMember{{ID: 1, Weight: 1}, {ID: 2, Weight: 3}},
+				Protocol:     "static",
+				ConfigSource: ConfigStatic,
+			},
+		},
+		Neighbors: []Neighbor{{
+			Address:          netip.MustParseAddr("192.168.1.50"),
+			LinkLayerAddress: net.HardwareAddr{0x52, 0x54, 0x00, 0x00, 0x00, 0x50},
+			ConfigSource:     ConfigStatic,
+		}},
+		DNS: []DNSServer{
+			{Addr: netip.MustParseAddr("192.168.1.1")},
+			{Addr: netip.MustParseAddr("2001:db8::53"), Port: 853, ServerName: "dns.example.com"},
+			{Addr: netip.MustParseAddr("2001:db8::1")},
+		},
+		NTP: []NTPServer{
+			{Name: "time.example.com"},
+			{Addr: netip.MustParseAddr("192.168.1.1")},
+		},
+		Domains: []Domain{
+			{Name: "example.com"},
+			{Name: "lan.example.com"},
+			{Name: "corp.example.com", RoutingOnly: true},
+		},
+		DHCPv4: &DHCPLease{
+			Address:        netip.MustParsePrefix("192.168.1.100/24"),
+			Server:         netip.MustParseAddr("192.168.1.1"),
+			Router:         netip.MustParseAddr("192.168.1.1"),
+			Expires:        time.Unix(86500, 0),
+			T1:             time.Unix(43300, 0),
+			T2:             time.Unix(75700, 0),
+			PrivateOptions: []DHCPOption{{Code: 224, Data: []byte{1, 2, 3, 4}}},
+		},
+		DHCPv6: &DHCPv6Lease{
+			DUID:      []byte{0, 4, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			IAID:      1263157334,
+			Server:    netip.MustParseAddr("fe80::2"),
+			T1:        time.Unix(2000, 0),
+			T2:        time.Unix(3400, 0),
+			Addresses: []netip.Prefix{netip.MustParsePrefix("2001:db8:ffff::10/128")},
+			Prefixes: []DelegatedPrefix{{
+				Prefix:         netip.MustParsePrefix("2001:db8:1234::/56"),
+				PreferredUntil: time.Unix(3800, 0),
+				ValidUntil:     time.Unix(7400, 0),
+			}},
+		},
+		DHCPServerLeases: []DHCPServerLease{
+			{
+				ClientID:     []byte{1, 0x52, 0x54, 0, 0, 0, 0x0a},
+				Address:      netip.MustParseAddr("192.168.1.110"),
+				HardwareAddr: net.HardwareAddr{0x52, 0x54, 0, 0, 0, 0x0a},
+				Expires:      time.Unix(3700, 0),
+			},
+			{
+				ClientID:     []byte{1, 0x52, 0x54, 0, 0, 0, 0x0b},
+				Address:      netip.MustParseAddr("192.168.1.20"),
+				HardwareAddr: net.HardwareAddr{0x52, 0x54, 0, 0, 0, 0x0b},
+				Static:       true,
+			},
+		},
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(addrEqual),
+		cmp.Comparer(prefixEqual),
+		cmpopts.IgnoreFields(LinkDescription{}, "Raw"),
+	}
+
+	if diff := cmp.Diff(want, got, opts...); diff != "" {
+		t.Fatalf("unexpected link description (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseLinkDescriptionSystemd252(t *testing.T) {
+	// systemd 252 omits the DHCPv4 client state and spells the address
+	// lifetime keys differently.
+	got := testLinkDescription(t, "link-nwdtest1-v252.json")
+
+	if got.Name != "nwdtest1" || got.OperationalState != OperationalRoutable || got.OnlineState != OnlineOnline {
+		t.Fatalf("unexpected link state: %q, %q, %q", got.Name, got.OperationalState, got.OnlineState)
+	}
+
+	if got.DHCPv4 != nil || got.DHCPv6 != nil {
+		t.Fatalf("unexpected DHCP state: %+v, %+v", got.DHCPv4, got.DHCPv6)
+	}
+
+	if len(got.Addresses) != 3 {
+		t.Fatalf("unexpected addresses: %+v", got.Addresses)
+	}
+	a := got.Addresses[0]
+	if a.Prefix != netip.MustParsePrefix("10.231.0.117/24") || a.ConfigSource != ConfigDHCPv4 ||
+		!a.ValidLifetime.Equal(time.Unix(10522, 232912000)) {
+		t.Fatalf("unexpected DHCPv4 address: %+v", a)
+	}
+
+	nd, err := parseNetworkDescription(testBootClock, []byte(testFixture(t, "manager-v252.json")))
+	if err != nil {
+		t.Fatalf("failed to parse description: %v", err)
+	}
+
+	if len(nd.Links) != 6 || nd.Links[5].Name != "nwdtest0" || nd.OperationalState != "" {
+		t.Fatalf("unexpected network description: %+v", nd)
+	}
+
+	want := []RoutingPolicyRule{{
+		Priority:          1000,
+		To:                netip.MustParsePrefix("10.232.0.0/24"),
+		OutgoingInterface: "nwdtest0",
+		Table:             1234,
+		Type:              "table",
+		Protocol:          "static",
+		ConfigSource:      ConfigStatic,
+	}}
+
+	if diff := cmp.Diff(want, nd.RoutingPolicyRules, cmp.Comparer(prefixEqual)); diff != "" {
+		t.Fatalf("unexpected routing policy rules (-want +got):\n%s", diff)
+	}
+}
+
+func TestOperationalStateRangeContains(t *testing.T) {
+	tests := []struct {
+		name string
+		r    OperationalStateRange
+		s    OperationalState
+		ok   bool
+	}{
+		{
+			name: "unbounded",
+			s:    OperationalOff,
+			ok:   true,
+		},
+		{
+			name: "below",
+			r:    OperationalStateRange{Min: OperationalDegraded, Max: OperationalRoutable},
+			s:    OperationalCarrier,
+		},
+		{
+			name: "within",
+			r:    OperationalStateRange{Min: OperationalDegraded, Max: OperationalRoutable},
+			s:    OperationalDegraded,
+			ok:   true,
+		},
+		{
+			name: "above",
+			r:    OperationalStateRange{Min: OperationalCarrier, Max: OperationalDegraded},
+			s:    OperationalRoutable,
+		},
+		{
+			name: "no maximum",
+			r:    OperationalStateRange{Min: OperationalCarrier},
+			s:    OperationalRoutable,
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.ok, tt.r.Contains(tt.s)); diff != "" {
+				t.Fatalf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseLinkDescriptionRaw(t *testing.T) {
+	// Fields unknown to this package must be ignored, but retained in Raw.
+	const b = `{"Index":2,"Name":"eth0","FutureField":{"Enabled":true}}`
+
+	d, err := parseLinkDescription(testBootClock, []byte(b))
+	if err != nil {
+		t.Fatalf("failed to parse link description: %v", err)
+	}
+
+	if d.Index != 2 || d.Name != "eth0" {
+		t.Fatalf("unexpected link: %d, %q", d.Index, d.Name)
+	}
+
+	var raw struct{ FutureField struct{ Enabled bool } }
+	if err := json.Unmarshal(d.Raw, &raw); err != nil {
+		t.Fatalf("failed to unmarshal raw description: %v", err)
+	}
+	if !raw.FutureField.Enabled {
+		t.Fatal("raw description did not retain unknown field")
+	}
+}
+
+func TestParseLinkDescriptionError(t *testing.T) {
+	if _, err := parseLinkDescription(testBootClock, []byte(`{`)); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+// testBootClock is a bootClock for which CLOCK_BOOTTIME timestamps are equal to
+// the Unix epoch.
+var testBootClock = bootClock{now: time.Unix(1000, 0), boot: 1000 * time.Second}
+
+// testLinkDescription parses a LinkDescription from a testdata fixture file.
+func testLinkDescription(t *testing.T, file string) *LinkDescription {
+	t.Helper()
+
+	d, err := parseLinkDescription(testBootClock, []byte(testFixture(t, file)))
+	if err != nil {
+		t.Fatalf("failed to parse link description: %v", err)
+	}
+
+	return d
+}
+
+func TestParseNetworkDescription(t *testing.T) {
+	got, err := parseNetworkDescription(testBootClock, []byte(testFixture(t, "manager.json")))
+	if err != nil {
+		t.Fatalf("failed to parse description: %v", err)
+	}
+
+	want := &NetworkDescription{
+		OperationalState: OperationalRoutable,
+		CarrierState:     CarrierCarrier,
+		AddressState:     AddressRoutable,
+		IPv4AddressState: AddressRoutable,
+		IPv6AddressState: AddressRoutable,
+		OnlineState:      OnlineOnline,
+		NamespaceID:      4026531840,
+		DNS: []DNSServer{
+			{Addr: netip.MustParseAddr("192.0.2.53")},
+			{Addr: netip.MustParseAddr("2001:db8::53"), Port: 853, ServerName: "dns.example.com"},
+		},
+		NTP: []NTPServer{
+			{Name: "time.example.com"},
+			{Addr: netip.MustParseAddr("192.0.2.123")},
+		},
+		Domains: []Domain{
+			{Name: "example.com"},
+			{Name: "corp.example.com", RoutingOnly: true},
+		},
+		RoutingPolicyRules: []RoutingPolicyRule{
+			{
+				Priority:          100,
+				From:              netip.MustParsePrefix("192.168.1.0/24"),
+				FirewallMark:      16,
+				FirewallMask:      255,
+				IncomingInterface: "eth0",
+				Table:             100,
+				Type:              "table",
+				Protocol:          "static",
+				ConfigSource:      ConfigStatic,
+			},
+			{
+				Priority:     200,
+				To:           netip.MustParsePrefix("2001:db8::/32"),
+				Invert:       true,
+				Table:        254,
+				Type:         "table",
+				Protocol:     "static",
+				ConfigSource: ConfigStatic,
+			},
+		},
+		Links: []LinkDescription{
+			{
+				Index:               1,
+				Name:                "lo",
+				Type:                "loopback",
+				AdministrativeState: AdministrativeUnmanaged,
+				OperationalState:    OperationalCarrier,
+				CarrierState:        CarrierCarrier,
+				AddressState:        AddressOff,
+				IPv4AddressState:    AddressOff,
+				IPv6AddressState:    AddressOff,
+				OnlineState:         "unknown",
+			},
+			{
+				Index:               2,
+				Name:                "eth0",
+				Type:                "ether",
+				AdministrativeState: AdministrativeConfigured,
+				OperationalState:    OperationalRoutable,
+				CarrierState:        CarrierCarrier,
+				AddressState:        AddressRoutable,
+				IPv4AddressState:    AddressRoutable,
+				IPv6AddressState:    AddressRoutable,
+				OnlineState:         OnlineOnline,
+				Driver:              "virtio_net",
+				NetworkFile:         "/etc/systemd/network/10-eth0.network",
+				RequiredForOnline:   true,
+				RequiredOperationalStateForOnline: OperationalStateRange{
+					Min: OperationalDegraded,
+					Max: OperationalRoutable,
+				},
+				RequiredFamilyForOnline: FamilyAny,
+			},
+		},
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(addrEqual),
+		cmp.Comparer(prefixEqual),
+		cmpopts.IgnoreFields(LinkDescription{}, "Raw"),
+		cmpopts.IgnoreFields(NetworkDescription{}, "Raw"),
+	}
+
+	if diff := cmp.Diff(want, got, opts...); diff != "" {
+		t.Fatalf("unexpected description (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,471 @@
+package networkd
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"time"
+)
+
+// A DHCPLease is a DHCPv4 lease acquired by networkd's DHCP client.
+type DHCPLease struct {
+	// Address is the leased address and its subnet prefix length.
+	Address netip.Prefix
+
+	// Server is the address of the DHCP server which granted the lease.
+	Server netip.Addr
+
+	// Router is the default gateway provided by the lease, if any.
+	Router netip.Addr
+
+	// Expires is the time at which the lease expires, and T1 and T2 are the
+	// times at which it will be renewed and rebound. Each is the zero time if
+	// it is unset or the lease does not expire.
+	Expires, T1, T2 time.Time
+
+	// PrivateOptions are the site-specific options (codes 224 through 254)
+	// sent by the DHCP server.
+	PrivateOptions []DHCPOption
+
+	// The remaining fields are stored by networkd in its lease files but are
+	// not included in link descriptions, so they are only set by
+	// ParseDHCPLease and ReadDHCPLeases.
+
+	// StaticRoutes and ClasslessRoutes are the routes sent by the DHCP server
+	// in the static route (33) and classless static route (121) options.
+	StaticRoutes, ClasslessRoutes []DHCPRoute
+
+	// NextServer is the address of the boot server, and RootPath is the path
+	// to the client's root disk from the root path option (17).
+	NextServer netip.Addr
+	RootPath   string
+
+	// VendorSpecific is the raw data of the vendor-specific information option
+	// (43), which can be decoded using ParseDHCPOptions if the vendor
+	// encapsulates options within it.
+	VendorSpecific []byte
+}
+
+// A DHCPRoute is a route sent by a DHCP server.
+type DHCPRoute struct {
+	Destination netip.Prefix
+	Gateway     netip.Addr
+}
+
+// A DHCPOption is a raw DHCP option.
+type DHCPOption struct {
+	Code uint8
+	Data []byte
+}
+
+// ParseDHCPOptions parses a sequence of DHCP options in their wire format of
+// code, length, and data, such as those encapsulated in the vendor-specific
+// information option. Pad options are skipped, and parsing stops at an end
+// option.
+func ParseDHCPOptions(b []byte) ([]DHCPOption, error) {
+	var opts []DHCPOption
+	for len(b) > 0 {
+		switch b[0] {
+		case 0:
+			// Pad.
+			b = b[1:]
+			continue
+		case 255:
+			// End.
+			return opts, nil
+		}
+
+		if len(b) < 2 {
+			return nil, fmt.Errorf("truncated DHCP option %d", b[0])
+		}
+
+		code, n := b[0], int(b[1])
+		if len(b) < 2+n {
+			return nil, fmt.Errorf("DHCP option %d length %d exceeds remaining data", code, n)
+		}
+
+		opts = append(opts, DHCPOption{Code: code, Data: b[2 : 2+n : 2+n]})
+		b = b[2+n:]
+	}
+
+	return opts, nil
+}
+
+// DHCPLease returns the link's current DHCPv4 lease. If the link has no
+// DHCPv4 lease, an error compatible with `errors.Is(err, os.ErrNotExist)` is
+// returned.
+func (ls *LinkService) DHCPLease(ctx context.Context) (*DHCPLease, error) {
+	clk, err := newBootClock()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ls.DescribeRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jd, err := decodeLinkDescription(b)
+	if err != nil {
+		return nil, err
+	}
+
+	l := jd.dhcpLease(clk)
+	if l == nil {
+		return nil, fmt.Errorf("link %q has no DHCPv4 lease: %w", ls.l.Name, os.ErrNotExist)
+	}
+
+	return l, nil
+}
+
+// A DHCPLeaseExpiry indicates that a link's DHCPv4 lease will soon expire.
+type DHCPLeaseExpiry struct {
+	Lease   *DHCPLease
+	Expires time.Time
+}
+
+// WatchDHCPLeaseExpiry watches the link's DHCPv4 lease and sends a
+// DHCPLeaseExpiry on the returned channel once the lease is due to expire
+// within the before duration without having been renewed, so that state which
+// depends on the lease can be renewed or rebound ahead of time. Each lease is
+// reported at most once, and a renewed lease is reported again as it nears its
+// new expiry. A reported lease is checked for renewal every minute, as
+// networkd may renew a lease without changing any link properties. Events are
+// sent until ctx is canceled, at which point the channel is closed and the
+// subscription is ended.
+func (ls *LinkService) WatchDHCPLeaseExpiry(ctx context.Context, before time.Duration) (<-chan DHCPLeaseExpiry, error) {
+	clk, err := newBootClock()
+	if err != nil {
+		return nil, err
+	}
+
+	return ls.watchDHCPLeaseExpiry(ctx, clk, before, time.Minute)
+}
+
+// watchDHCPLeaseExpiry implements WatchDHCPLeaseExpiry using clk to convert
+// lease timestamps, checking a reported lease for renewal at the recheck
+// interval.
+func (ls *LinkService) watchDHCPLeaseExpiry(ctx context.Context, clk bootClock, before, recheck time.Duration) (<-chan DHCPLeaseExpiry, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// networkd does not signal lease renewals, but changes to the link's
+	// properties indicate that a lease may have been acquired or lost.
+	_, changes, err := ls.watchProperties(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	describe := func() (*jsonLinkDescription, error) {
+		b, err := ls.DescribeRaw(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return decodeLinkDescription(b)
+	}
+
+	jd, err := describe()
+	if err != nil {
+		cancel()
+		for range changes {
+		}
+		return nil, err
+	}
+
+	out := make(chan DHCPLeaseExpiry)
+	go func() {
+		defer close(out)
+		defer func() {
+			// Stop watching and wait for the subscription to end.
+			cancel()
+			for range changes {
+			}
+		}()
+
+		var (
+			notified time.Time
+			timer    *time.Timer
+			timerC   <-chan time.Time
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			if timer != nil {
+				timer.Stop()
+				timer, timerC = nil, nil
+			}
+
+			if l := jd.dhcpLease(clk); l != nil && !l.Expires.IsZero() {
+				if !l.Expires.Equal(notified) {
+					// Check the lease again when it is due to expire, in
+					// case it has been renewed in the meantime.
+					if d := time.Until(l.Expires.Add(-before)); d > 0 {
+						timer = time.NewTimer(d)
+					} else {
+						select {
+						case <-ctx.Done():
+							return
+						case out <- DHCPLeaseExpiry{Lease: l, Expires: l.Expires}:
+						}
+						notified = l.Expires
+					}
+				}
+
+				if timer == nil {
+					// The lease has been reported, so check it periodically
+					// for a renewal which did not change any link properties.
+					timer = time.NewTimer(recheck)
+				}
+				timerC = timer.C
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-changes:
+				if !ok {
+					return
+				}
+			case <-timerC:
+			}
+
+			// Keep the last known lease if the link cannot be described.
+			if next, err := describe(); err == nil {
+				jd = next
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// dhcpLease produces a DHCPLease from the DHCPv4 client state and the
+// addresses and routes attributed to DHCPv4 in a link description, using clk to
+// convert lease timestamps. If the link has no DHCPv4 lease, it returns nil.
+func (jd *jsonLinkDescription) dhcpLease(clk bootClock) *DHCPLease {
+	if jd.DHCPv4Client == nil || jd.DHCPv4Client.Lease == nil {
+		return nil
+	}
+
+	var (
+		jl = jd.DHCPv4Client.Lease
+		l  = &DHCPLease{
+			T1: clk.Time(jl.Timeout1USec),
+			T2: clk.Time(jl.Timeout2USec),
+		}
+	)
+
+	for _, a := range jd.Addresses {
+		if a.ConfigSource != ConfigDHCPv4 {
+			continue
+		}
+
+		l.Address = netip.PrefixFrom(netip.Addr(a.Address), a.PrefixLength)
+		l.Server = netip.Addr(a.ConfigProvider)
+		l.Expires = clk.Time(a.ValidLifetimeUSec)
+		break
+	}
+
+	for _, r := range jd.Routes {
+		// The router is the gateway of the DHCPv4 default route.
+		if r.ConfigSource == ConfigDHCPv4 && r.DestinationPrefixLength == 0 {
+			l.Router = netip.Addr(r.Gateway)
+			break
+		}
+	}
+
+	for _, o := range jd.DHCPv4Client.PrivateOptions {
+		l.PrivateOptions = append(l.PrivateOptions, DHCPOption{
+			Code: o.Option,
+			Data: o.PrivateOptionData,
+		})
+	}
+
+	return l
+}
+
+// A DHCPv6PrefixDelegation is the result of DHCPv6 prefix delegation performed
+// by networkd's DHCPv6 client on an upstream link.
+type DHCPv6PrefixDelegation struct {
+	// Server is the address of the DHCPv6 server which delegated the
+	// prefixes, if known.
+	Server netip.Addr
+
+	// T1 and T2 are the times at which the lease will be renewed and
+	// rebound, or the zero time if unset.
+	T1, T2 time.Time
+
+	// Prefixes are the prefixes delegated by the server.
+	Prefixes []DelegatedPrefix
+}
+
+// A DelegatedPrefix is an IPv6 prefix delegated by a DHCPv6 server.
+type DelegatedPrefix struct {
+	Prefix netip.Prefix
+
+	// PreferredUntil and ValidUntil are the times at which the prefix is no
+	// longer preferred and valid. Each is the zero time if the lifetime is
+	// infinite.
+	PreferredUntil, ValidUntil time.Time
+}
+
+// DHCPv6PrefixDelegation returns the results of DHCPv6 prefix delegation on
+// this link. If the link has no DHCPv6 lease with delegated prefixes, an error
+// compatible with `errors.Is(err, os.ErrNotExist)` is returned.
+func (ls *LinkService) DHCPv6PrefixDelegation(ctx context.Context) (*DHCPv6PrefixDelegation, error) {
+	clk, err := newBootClock()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ls.DescribeRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jd, err := decodeLinkDescription(b)
+	if err != nil {
+		return nil, err
+	}
+
+	pd := jd.dhcpv6PrefixDelegation(clk)
+	if pd == nil {
+		return nil, fmt.Errorf("link %q has no delegated DHCPv6 prefixes: %w", ls.l.Name, os.ErrNotExist)
+	}
+
+	return pd, nil
+}
+
+// dhcpv6PrefixDelegation produces a DHCPv6PrefixDelegation from the DHCPv6
+// lease in a link description, using clk to convert lease timestamps. If no
+// prefixes were delegated, it returns nil.
+func (jd *jsonLinkDescription) dhcpv6PrefixDelegation(clk bootClock) *DHCPv6PrefixDelegation {
+	l := jd.dhcpv6Lease(clk)
+	if l == nil || len(l.Prefixes) == 0 {
+		return nil
+	}
+
+	return &DHCPv6PrefixDelegation{
+		Server:   l.Server,
+		T1:       l.T1,
+		T2:       l.T2,
+		Prefixes: l.Prefixes,
+	}
+}
+
+// A DHCPIdentity contains the identifiers which networkd's DHCP clients on a
+// link present to DHCP servers.
+type DHCPIdentity struct {
+	// ClientID is the DHCPv4 client identifier, or nil if the DHCPv4 client
+	// is not enabled.
+	ClientID []byte
+
+	// DUID and IAID are the DHCP unique identifier and identity association
+	// identifier used by the DHCPv6 client. DUID is nil if the DHCPv6 client
+	// is not enabled.
+	DUID []byte
+	IAID uint32
+}
+
+// DHCPIdentity returns the identifiers used by the link's DHCP clients, so that
+// reservations for the link can be provisioned on DHCP servers. Unlike the
+// leases, the identifiers are available before a lease is acquired. If neither
+// DHCP client is enabled on the link, an error compatible with
+// `errors.Is(err, os.ErrNotExist)` is returned.
+func (ls *LinkService) DHCPIdentity(ctx context.Context) (*DHCPIdentity, error) {
+	b, err := ls.DescribeRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jd, err := decodeLinkDescription(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if jd.DHCPv4Client == nil && jd.DHCPv6Client == nil {
+		return nil, fmt.Errorf("link %q has no DHCP client: %w", ls.l.Name, os.ErrNotExist)
+	}
+
+	var id DHCPIdentity
+	if c := jd.DHCPv4Client; c != nil {
+		id.ClientID = c.ClientIdentifier
+	}
+	if c := jd.DHCPv6Client; c != nil {
+		id.DUID, id.IAID = c.DUID, c.IAID
+	}
+
+	return &id, nil
+}
+
+// A DHCPv6Lease is the state of networkd's DHCPv6 client on a link.
+type DHCPv6Lease struct {
+	// DUID and IAID are the DHCP unique identifier and identity association
+	// identifier used by the client.
+	DUID []byte
+	IAID uint32
+
+	// Server is the address of the DHCPv6 server which granted the lease, if
+	// known.
+	Server netip.Addr
+
+	// T1 and T2 are the times at which the lease will be renewed and
+	// rebound, or the zero time if unset.
+	T1, T2 time.Time
+
+	// Addresses are the non-temporary (IA_NA) addresses assigned by the
+	// server.
+	Addresses []netip.Prefix
+
+	// Prefixes are the prefixes delegated (IA_PD) by the server.
+	Prefixes []DelegatedPrefix
+}
+
+// dhcpv6Lease produces a DHCPv6Lease from the DHCPv6 client state and the
+// addresses attributed to DHCPv6 in a link description, using clk to convert
+// lease timestamps. If the link has no DHCPv6 lease, it returns nil.
+func (jd *jsonLinkDescription) dhcpv6Lease(clk bootClock) *DHCPv6Lease {
+	c := jd.DHCPv6Client
+	if c == nil || c.Lease == nil {
+		return nil
+	}
+
+	l := &DHCPv6Lease{
+		DUID: c.DUID,
+		IAID: c.IAID,
+		T1:   clk.Time(c.Lease.Timeout1USec),
+		T2:   clk.Time(c.Lease.Timeout2USec),
+	}
+
+	for _, a := range jd.Addresses {
+		switch a.ConfigSource {
+		case ConfigDHCPv6:
+			l.Addresses = append(l.Addresses, netip.PrefixFrom(netip.Addr(a.Address), a.PrefixLength))
+		case ConfigDHCPPD:
+		default:
+			continue
+		}
+
+		// networkd attributes addresses acquired via DHCPv6 to the server
+		// which provided them.
+		if !l.Server.IsValid() {
+			l.Server = netip.Addr(a.ConfigProvider)
+		}
+	}
+
+	for _, p := range c.Prefixes {
+		l.Prefixes = append(l.Prefixes, DelegatedPrefix{
+			Prefix:         netip.PrefixFrom(netip.Addr(p.Prefix), p.PrefixLength),
+			PreferredUntil: clk.Time(p.PreferredLifetimeUSec),
+			ValidUntil:     clk.Time(p.ValidLifetimeUSec),
+		})
+	}
+
+	return l
+}
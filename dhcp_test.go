@@ -0,0 +1,343 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLinkServiceDHCPLease(t *testing.T) {
+	ls := testLinkService(t, "Describe", nil, testFixture(t, "link-eth0.json"))
+
+	l, err := ls.DHCPLease(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get DHCP lease: %v", err)
+	}
+
+	want := &DHCPLease{
+		Address:        netip.MustParsePrefix("192.168.1.100/24"),
+		Server:         netip.MustParseAddr("192.168.1.1"),
+		Router:         netip.MustParseAddr("192.168.1.1"),
+		Expires:        time.Unix(86500, 0),
+		T1:             time.Unix(43300, 0),
+		T2:             time.Unix(75700, 0),
+		PrivateOptions: []DHCPOption{{Code: 224, Data: []byte{1, 2, 3, 4}}},
+	}
+
+	// The lease times depend on the system's boot time, so check them using
+	// a fixed clock.
+	opts := []cmp.Option{cmp.Comparer(addrEqual), cmp.Comparer(prefixEqual)}
+	if diff := cmp.Diff(want, l, append(opts, cmpopts.IgnoreFields(DHCPLease{}, "Expires", "T1", "T2"))...); diff != "" {
+		t.Fatalf("unexpected lease (-want +got):\n%s", diff)
+	}
+
+	jd, err := decodeLinkDescription([]byte(testFixture(t, "link-eth0.json")))
+	if err != nil {
+		t.Fatalf("failed to decode link description: %v", err)
+	}
+
+	if diff := cmp.Diff(want, jd.dhcpLease(testBootClock), opts...); diff != "" {
+		t.Fatalf("unexpected lease times (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkServiceDHCPLeaseNotExist(t *testing.T) {
+	ls := testLinkService(t, "Describe", nil, `{"Index":2,"Name":"eth0"}`)
+
+	if _, err := ls.DHCPLease(context.Background()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, but got: %v", err)
+	}
+}
+
+func TestLinkServiceDHCPIdentity(t *testing.T) {
+	ls := testLinkService(t, "Describe", nil, testFixture(t, "link-eth0.json"))
+
+	id, err := ls.DHCPIdentity(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get DHCP identity: %v", err)
+	}
+
+	want := &DHCPIdentity{
+		ClientID: []byte{255, 0, 0, 0, 1, 0, 4, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		DUID:     []byte{0, 4, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		IAID:     1263157334,
+	}
+
+	if diff := cmp.Diff(want, id); diff != "" {
+		t.Fatalf("unexpected identity (-want +got):\n%s", diff)
+	}
+
+	ls = testLinkService(t, "Describe", nil, `{"Index":2,"Name":"eth0"}`)
+	if _, err := ls.DHCPIdentity(context.Background()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, but got: %v", err)
+	}
+}
+
+func TestParseDHCPOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		opts []DHCPOption
+		ok   bool
+	}{
+		{
+			name: "truncated",
+			b:    []byte{1},
+		},
+		{
+			name: "short data",
+			b:    []byte{1, 2, 0xff},
+		},
+		{
+			name: "empty",
+			ok:   true,
+		},
+		{
+			name: "OK",
+			b:    []byte{0, 1, 2, 0xca, 0xfe, 0, 2, 0, 255, 3, 1, 1},
+			opts: []DHCPOption{
+				{Code: 1, Data: []byte{0xca, 0xfe}},
+				{Code: 2, Data: []byte{}},
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ParseDHCPOptions(tt.b)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse options: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if diff := cmp.Diff(tt.opts, opts); diff != "" {
+				t.Fatalf("unexpected options (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLinkServiceWatchDHCPLeaseExpiry(t *testing.T) {
+	c, signals, active := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLink.ObjectPath: testLinkProperties(),
+	})
+
+	// The fixture's DHCPv4 address expires in an hour.
+	var (
+		now     = time.Now()
+		clk     = bootClock{now: now, boot: 86500*time.Second - time.Hour}
+		expires = now.Add(time.Hour)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := c.Link(testLink).watchDHCPLeaseExpiry(ctx, clk, 2*time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to watch lease expiry: %v", err)
+	}
+
+	e := <-events
+	if diff := cmp.Diff(netip.MustParsePrefix("192.168.1.100/24"), e.Lease.Address, cmp.Comparer(prefixEqual)); diff != "" {
+		t.Fatalf("unexpected lease address (-want +got):\n%s", diff)
+	}
+	if !e.Expires.Equal(expires) {
+		t.Fatalf("unexpected expiry: %v", e.Expires)
+	}
+
+	// The same lease is not reported again.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded")
+
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	for range events {
+	}
+
+	// A lease which is not yet due to expire is not reported.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	events, err = c.Link(testLink).watchDHCPLeaseExpiry(ctx, clk, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to watch lease expiry: %v", err)
+	}
+
+	for e := range events {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestLinkServiceWatchDHCPLeaseExpiryRenewed(t *testing.T) {
+	c, _, active := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLink.ObjectPath: testLinkProperties(),
+	})
+
+	// Once renewed, the fixture's DHCPv4 address expires an hour later. The
+	// renewal is not accompanied by any property change.
+	var renewed atomic.Bool
+	describe := c.call
+	c.call = func(ctx context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
+		if err := describe(ctx, service, method, op, out, args...); err != nil {
+			return err
+		}
+
+		if method == interfacePath("Link", "Describe") && op == testLink.ObjectPath && renewed.Load() {
+			s := out.(*string)
+			*s = strings.ReplaceAll(*s, "86500000000", "90100000000")
+		}
+
+		return nil
+	}
+
+	var (
+		now = time.Now()
+		clk = bootClock{now: now, boot: 86500*time.Second - time.Hour}
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := c.Link(testLink).watchDHCPLeaseExpiry(ctx, clk, 3*time.Hour, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to watch lease expiry: %v", err)
+	}
+
+	if e := <-events; !e.Expires.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected expiry: %v", e.Expires)
+	}
+
+	renewed.Store(true)
+
+	select {
+	case e := <-events:
+		if !e.Expires.Equal(now.Add(2 * time.Hour)) {
+			t.Fatalf("unexpected renewed expiry: %v", e.Expires)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for renewed lease")
+	}
+
+	cancel()
+	for range events {
+	}
+
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestLinkServiceDHCPv6PrefixDelegation(t *testing.T) {
+	ls := testLinkService(t, "Describe", nil, testFixture(t, "link-eth0.json"))
+
+	pd, err := ls.DHCPv6PrefixDelegation(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get DHCPv6 prefix delegation: %v", err)
+	}
+
+	want := &DHCPv6PrefixDelegation{
+		Server: netip.MustParseAddr("fe80::2"),
+		T1:     time.Unix(2000, 0),
+		T2:     time.Unix(3400, 0),
+		Prefixes: []DelegatedPrefix{{
+			Prefix:         netip.MustParsePrefix("2001:db8:1234::/56"),
+			PreferredUntil: time.Unix(3800, 0),
+			ValidUntil:     time.Unix(7400, 0),
+		}},
+	}
+
+	// The lease times depend on the system's boot time, so check them using
+	// a fixed clock.
+	opts := []cmp.Option{cmp.Comparer(addrEqual), cmp.Comparer(prefixEqual)}
+	if diff := cmp.Diff(want, pd, append(opts,
+		cmpopts.IgnoreFields(DHCPv6PrefixDelegation{}, "T1", "T2"),
+		cmpopts.IgnoreFields(DelegatedPrefix{}, "PreferredUntil", "ValidUntil"),
+	)...); diff != "" {
+		t.Fatalf("unexpected prefix delegation (-want +got):\n%s", diff)
+	}
+
+	jd, err := decodeLinkDescription([]byte(testFixture(t, "link-eth0.json")))
+	if err != nil {
+		t.Fatalf("failed to decode link description: %v", err)
+	}
+
+	if diff := cmp.Diff(want, jd.dhcpv6PrefixDelegation(testBootClock), opts...); diff != "" {
+		t.Fatalf("unexpected prefix delegation times (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkServiceDHCPv6PrefixDelegationNotExist(t *testing.T) {
+	ls := testLinkService(t, "Describe", nil, `{"Index":2,"Name":"eth0"}`)
+
+	if _, err := ls.DHCPv6PrefixDelegation(context.Background()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, but got: %v", err)
+	}
+}
+
+// testFixture returns the contents of a testdata fixture file as a string.
+func testFixture(t *testing.T, file string) string {
+	t.Helper()
+
+	b, err := os.ReadFile("testdata/" + file)
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	return string(b)
+}
+
+func addrEqual(x, y netip.Addr) bool     { return x == y }
+func prefixEqual(x, y netip.Prefix) bool { return x == y }
+
+func TestLinkDescriptionDHCPv6Server(t *testing.T) {
+	// Without a DHCPv6 address, the server is attributed via the address
+	// assigned from the delegated prefix, for both the lease and the prefix
+	// delegation.
+	jd, err := decodeLinkDescription([]byte(`{
+		"Addresses": [{
+			"Family": 10,
+			"Address": [32, 1, 13, 184, 18, 52, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1],
+			"PrefixLength": 64,
+			"ConfigSource": "DHCP-PD",
+			"ConfigProvider": [254, 128, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2]
+		}],
+		"DHCPv6Client": {
+			"Lease": {"LeaseTimestampUSec": 200000000},
+			"Prefixes": [{
+				"Prefix": [32, 1, 13, 184, 18, 52, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0],
+				"PrefixLength": 56
+			}]
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to decode link description: %v", err)
+	}
+
+	want := netip.MustParseAddr("fe80::2")
+	if got := jd.dhcpv6Lease(testBootClock).Server; got != want {
+		t.Fatalf("unexpected lease server: want %v, got %v", want, got)
+	}
+	if got := jd.dhcpv6PrefixDelegation(testBootClock).Server; got != want {
+		t.Fatalf("unexpected prefix delegation server: want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,219 @@
+package networkd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// A DHCPServerLease is a lease handed out by networkd's built-in DHCPv4 server.
+type DHCPServerLease struct {
+	ClientID     []byte
+	Address      netip.Addr
+	Gateway      netip.Addr
+	HardwareAddr net.HardwareAddr
+	Expires      time.Time
+
+	// Static reports whether the lease is a reservation configured by a
+	// DHCPServerStaticLease section of the link's .network file, rather than
+	// a dynamic assignment. Static leases do not expire.
+	Static bool
+}
+
+// A DHCPServerLeaseEvent indicates that a DHCPServerLease was granted or
+// renewed, or that it expired or was released when Removed is set.
+type DHCPServerLeaseEvent struct {
+	Lease   DHCPServerLease
+	Removed bool
+}
+
+// DHCPServerLeases fetches the leases currently handed out by networkd's DHCP
+// server on this link.
+func (ls *LinkService) DHCPServerLeases(ctx context.Context) ([]DHCPServerLease, error) {
+	clk, err := newBootClock()
+	if err != nil {
+		return nil, err
+	}
+
+	props, err := ls.c.getAll(ctx, ls.l.ObjectPath, interfacePath("DHCPServer"))
+	if err != nil {
+		return nil, fmt.Errorf("get DHCP server leases for link %q: %w", ls.l.Name, err)
+	}
+
+	return parseDHCPServerLeases(clk, props)
+}
+
+// WatchDHCPServerLeases subscribes to changes to the leases handed out by
+// networkd's DHCP server on this link. Events are sent on the returned channel
+// until ctx is canceled, at which point the channel is closed and the
+// subscription is ended.
+func (ls *LinkService) WatchDHCPServerLeases(ctx context.Context) (<-chan DHCPServerLeaseEvent, error) {
+	clk, err := newBootClock()
+	if err != nil {
+		return nil, err
+	}
+
+	leases, ch, err := watchProperties(ctx, ls.c, ls.l.ObjectPath, interfacePath("DHCPServer"),
+		func(props map[string]dbus.Variant) ([]DHCPServerLease, error) {
+			return parseDHCPServerLeases(clk, props)
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("watch DHCP server leases for link %q: %w", ls.l.Name, err)
+	}
+
+	out := make(chan DHCPServerLeaseEvent)
+	go func() {
+		defer close(out)
+
+		for next := range ch {
+			for _, e := range diffDHCPServerLeases(leases, next) {
+				select {
+				case <-ctx.Done():
+					// Keep draining ch until the subscription ends.
+				case out <- e:
+				}
+			}
+			leases = next
+		}
+	}()
+
+	return out, nil
+}
+
+// diffDHCPServerLeases produces DHCPServerLeaseEvents for the leases removed
+// from prev and the leases added or renewed in next.
+func diffDHCPServerLeases(prev, next []DHCPServerLease) []DHCPServerLeaseEvent {
+	find := func(leases []DHCPServerLease, addr netip.Addr) (DHCPServerLease, bool) {
+		for _, l := range leases {
+			if l.Address == addr {
+				return l, true
+			}
+		}
+
+		return DHCPServerLease{}, false
+	}
+
+	var events []DHCPServerLeaseEvent
+	for _, l := range prev {
+		if _, ok := find(next, l.Address); !ok {
+			events = append(events, DHCPServerLeaseEvent{Lease: l, Removed: true})
+		}
+	}
+	for _, l := range next {
+		p, ok := find(prev, l.Address)
+		if ok && p.Expires.Equal(l.Expires) && bytes.Equal(p.ClientID, l.ClientID) {
+			continue
+		}
+
+		events = append(events, DHCPServerLeaseEvent{Lease: l})
+	}
+
+	return events
+}
+
+// parseDHCPServerLeases unpacks DHCPServerLeases from the Leases property of
+// the networkd DHCPServer interface.
+func parseDHCPServerLeases(clk bootClock, props map[string]dbus.Variant) ([]DHCPServerLease, error) {
+	v, ok := props["Leases"]
+	if !ok {
+		return nil, nil
+	}
+
+	// Leases is an array of (family, client ID, address, gateway, hardware
+	// address, expiration) structures.
+	values, ok := v.Value().([][]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid DHCP server leases type: %T", v.Value())
+	}
+
+	leases := make([]DHCPServerLease, 0, len(values))
+	for _, vs := range values {
+		if l := len(vs); l != 6 {
+			return nil, fmt.Errorf("invalid number of DHCP server lease values: %d", l)
+		}
+
+		var (
+			clientID, cidOK = vs[1].([]byte)
+			rawAddr, addrOK = vs[2].([]byte)
+			rawGW, gwOK     = vs[3].([]byte)
+			hwAddr, hwOK    = vs[4].([]byte)
+			expires, expOK  = vs[5].(uint64)
+		)
+		if !cidOK || !addrOK || !gwOK || !hwOK || !expOK {
+			return nil, fmt.Errorf("invalid DHCP server lease: %v", vs)
+		}
+
+		addr, ok := netip.AddrFromSlice(rawAddr)
+		if !ok {
+			return nil, fmt.Errorf("invalid DHCP server lease address: %v", rawAddr)
+		}
+
+		// The gateway is all zeros when unset.
+		gw, _ := netip.AddrFromSlice(rawGW)
+		if gw.IsUnspecified() {
+			gw = netip.Addr{}
+		}
+
+		leases = append(leases, DHCPServerLease{
+			ClientID:     clientID,
+			Address:      addr,
+			Gateway:      gw,
+			HardwareAddr: hardwareAddr(hwAddr),
+			Expires:      clk.Time(expires),
+		})
+	}
+
+	return leases, nil
+}
+
+// dhcpServerLeases produces the dynamic and static DHCPServerLeases from a link
+// description.
+func (jd *jsonLinkDescription) dhcpServerLeases(clk bootClock) []DHCPServerLease {
+	if jd.DHCPServer == nil {
+		return nil
+	}
+
+	var leases []DHCPServerLease
+	for _, l := range jd.DHCPServer.Leases {
+		leases = append(leases, DHCPServerLease{
+			ClientID:     l.ClientID,
+			Address:      netip.Addr(l.Address),
+			HardwareAddr: hardwareAddr(l.HardwareAddress),
+			Expires:      clk.Time(l.ExpirationUSec),
+		})
+	}
+
+	for _, l := range jd.DHCPServer.StaticLeases {
+		sl := DHCPServerLease{
+			ClientID: l.ClientID,
+			Address:  netip.Addr(l.Address),
+			Static:   true,
+		}
+
+		// Static leases configured by MACAddress= use a client ID of the
+		// Ethernet hardware type followed by the address.
+		if id := l.ClientID; len(id) == 7 && id[0] == 1 {
+			sl.HardwareAddr = net.HardwareAddr(id[1:])
+		}
+
+		leases = append(leases, sl)
+	}
+
+	return leases
+}
+
+// hardwareAddr trims the zero padding from a 16 byte DHCP client hardware
+// address field when it contains an Ethernet address.
+func hardwareAddr(b []byte) net.HardwareAddr {
+	if len(b) == 16 && bytes.Equal(b[6:], make([]byte, 10)) {
+		b = b[:6]
+	}
+
+	return net.HardwareAddr(b)
+}
@@ -0,0 +1,158 @@
+package networkd
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var (
+	testServerLeaseA = []any{
+		uint32(afINET),
+		[]byte{0x01, 0x52, 0x54, 0x00, 0x00, 0x00, 0x0a},
+		[]byte{192, 168, 1, 10},
+		[]byte{192, 168, 1, 1},
+		append([]byte{0x52, 0x54, 0x00, 0x00, 0x00, 0x0a}, make([]byte, 10)...),
+		uint64(3600000000),
+	}
+
+	testServerLeaseB = []any{
+		uint32(afINET),
+		[]byte{0x01, 0x52, 0x54, 0x00, 0x00, 0x00, 0x0b},
+		[]byte{192, 168, 1, 11},
+		[]byte{0, 0, 0, 0},
+		append([]byte{0x52, 0x54, 0x00, 0x00, 0x00, 0x0b}, make([]byte, 10)...),
+		uint64(7200000000),
+	}
+)
+
+func TestParseDHCPServerLeases(t *testing.T) {
+	clk := bootClock{now: time.Unix(1000, 0), boot: 1000 * time.Second}
+
+	leases, err := parseDHCPServerLeases(clk, map[string]dbus.Variant{
+		"Leases": dbus.MakeVariant([][]any{testServerLeaseA, testServerLeaseB}),
+	})
+	if err != nil {
+		t.Fatalf("failed to parse leases: %v", err)
+	}
+
+	want := []DHCPServerLease{
+		{
+			ClientID:     []byte{0x01, 0x52, 0x54, 0x00, 0x00, 0x00, 0x0a},
+			Address:      netip.MustParseAddr("192.168.1.10"),
+			Gateway:      netip.MustParseAddr("192.168.1.1"),
+			HardwareAddr: net.HardwareAddr{0x52, 0x54, 0x00, 0x00, 0x00, 0x0a},
+			Expires:      time.Unix(3600, 0),
+		},
+		{
+			ClientID:     []byte{0x01, 0x52, 0x54, 0x00, 0x00, 0x00, 0x0b},
+			Address:      netip.MustParseAddr("192.168.1.11"),
+			HardwareAddr: net.HardwareAddr{0x52, 0x54, 0x00, 0x00, 0x00, 0x0b},
+			Expires:      time.Unix(7200, 0),
+		},
+	}
+
+	if diff := cmp.Diff(want, leases, cmp.Comparer(addrEqual)); diff != "" {
+		t.Fatalf("unexpected leases (-want +got):\n%s", diff)
+	}
+
+	// Leases with too few values or values of the wrong types are rejected.
+	bad := append([]any{}, testServerLeaseA...)
+	bad[5] = "tomorrow"
+
+	for _, vs := range [][]any{{uint32(afINET)}, bad} {
+		_, err = parseDHCPServerLeases(clk, map[string]dbus.Variant{
+			"Leases": dbus.MakeVariant([][]any{vs}),
+		})
+		if err == nil {
+			t.Fatal("expected an error, but none occurred")
+		}
+	}
+}
+
+func TestLinkServiceDHCPServerLeases(t *testing.T) {
+	c := &Client{
+		getAll: func(_ context.Context, op dbus.ObjectPath, iface string) (map[string]dbus.Variant, error) {
+			if diff := cmp.Diff(interfacePath("DHCPServer"), iface); diff != "" {
+				t.Fatalf("unexpected interface (-want +got):\n%s", diff)
+			}
+
+			return map[string]dbus.Variant{
+				"Leases": dbus.MakeVariant([][]any{testServerLeaseA, testServerLeaseB}),
+			}, nil
+		},
+	}
+
+	leases, err := c.Link(testLink).DHCPServerLeases(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get leases: %v", err)
+	}
+
+	want := []DHCPServerLease{
+		{
+			Address:      netip.MustParseAddr("192.168.1.10"),
+			HardwareAddr: net.HardwareAddr{0x52, 0x54, 0x00, 0x00, 0x00, 0x0a},
+		},
+		{
+			Address:      netip.MustParseAddr("192.168.1.11"),
+			HardwareAddr: net.HardwareAddr{0x52, 0x54, 0x00, 0x00, 0x00, 0x0b},
+		},
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(addrEqual),
+		cmpopts.IgnoreFields(DHCPServerLease{}, "ClientID", "Gateway", "Expires"),
+	}
+
+	if diff := cmp.Diff(want, leases, opts...); diff != "" {
+		t.Fatalf("unexpected leases (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkServiceWatchDHCPServerLeases(t *testing.T) {
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLink.ObjectPath: {"Leases": dbus.MakeVariant([][]any{testServerLeaseA})},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := c.Link(testLink).WatchDHCPServerLeases(ctx)
+	if err != nil {
+		t.Fatalf("failed to watch leases: %v", err)
+	}
+
+	// A new lease is granted, and then the original lease expires.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "DHCPServer", "Leases", [][]any{testServerLeaseA, testServerLeaseB})
+	signals <- testPropertiesChanged(testLink.ObjectPath, "DHCPServer", "Leases", [][]any{testServerLeaseB})
+
+	want := []DHCPServerLeaseEvent{
+		{Lease: DHCPServerLease{Address: netip.MustParseAddr("192.168.1.11")}},
+		{Lease: DHCPServerLease{Address: netip.MustParseAddr("192.168.1.10")}, Removed: true},
+	}
+
+	got := []DHCPServerLeaseEvent{<-events, <-events}
+
+	opts := []cmp.Option{
+		cmp.Comparer(addrEqual),
+		cmpopts.IgnoreFields(DHCPServerLease{}, "ClientID", "Gateway", "HardwareAddr", "Expires"),
+	}
+
+	if diff := cmp.Diff(want, got, opts...); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	cancel()
+	for range events {
+	}
+
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
@@ -2,4 +2,8 @@ module github.com/mdlayher/networkd
 
 go 1.21
 
-require github.com/godbus/dbus/v5 v5.1.0
+require (
+	github.com/godbus/dbus/v5 v5.1.0
+	github.com/google/go-cmp v0.6.0
+	golang.org/x/sys v0.15.0
+)
@@ -0,0 +1,455 @@
+// Command describegen generates the Go types used to decode networkd's JSON
+// Describe output from captured fixtures of that output.
+//
+// Each fixture is the output of either the Manager or the Link Describe
+// method. Because networkd omits fields which are unset and adds fields in
+// new releases, the generated types contain the union of the fields found in
+// all fixtures, so fixtures from several systemd versions should be provided.
+//
+// Usage:
+//
+//	describegen -o zdescribe.go testdata/*.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A typeSpec specifies a generated struct type.
+type typeSpec struct {
+	// Name is the name of the generated type, and Doc is its doc comment.
+	Name, Doc string
+
+	// Ref is the type used to refer to the generated type from other types,
+	// if it differs from Name.
+	Ref string
+}
+
+// Root types for Manager and Link descriptions.
+var (
+	managerSpec = typeSpec{
+		Name: "jsonNetworkDescription",
+		Doc:  "is the JSON representation of networkd's global description.",
+	}
+
+	linkSpec = typeSpec{
+		Name: "jsonLink",
+		Doc:  "is the JSON representation of a link description produced by networkd.",
+		// jsonLinkDescription embeds jsonLink and also retains the raw JSON.
+		Ref: "jsonLinkDescription",
+	}
+)
+
+// objects maps the keys of JSON objects, or arrays of objects, to the types
+// which decode them. Objects with keys not listed here are not decoded.
+var objects = map[string]typeSpec{
+	"Addresses": {
+		Name: "jsonAddress",
+		Doc:  "is the JSON representation of an address produced by networkd.",
+	},
+	"DHCPv4Client": {
+		Name: "jsonDHCPv4Client",
+		Doc:  "is the JSON representation of networkd's DHCPv4 client state for a link.",
+	},
+	"DHCPServer": {
+		Name: "jsonDHCPServer",
+		Doc:  "is the JSON representation of networkd's DHCP server state for a link.",
+	},
+	"DHCPv6Client": {
+		Name: "jsonDHCPv6Client",
+		Doc:  "is the JSON representation of networkd's DHCPv6 client state for a link.",
+	},
+	"DNS": {
+		Name: "jsonDNS",
+		Doc:  "is the JSON representation of a DNS server produced by networkd.",
+	},
+	"Group": {
+		Name: "jsonNextHopGroupMember",
+		Doc:  "is the JSON representation of a member of a next hop group produced by networkd.",
+	},
+	"Interfaces": linkSpec,
+	"Leases": {
+		Name: "jsonDHCPServerLease",
+		Doc:  "is the JSON representation of a lease handed out by networkd's DHCP server.",
+	},
+	"LLDP": {
+		Name: "jsonLLDPNeighbor",
+		Doc:  "is the JSON representation of an LLDP neighbor produced by networkd.",
+	},
+	"Lease": {
+		Name: "jsonDHCPLease",
+		Doc: "is the JSON representation of the timing information for a DHCP lease. " +
+			"All timestamps are in microseconds of CLOCK_BOOTTIME.",
+	},
+	"MultiPathRoutes": {
+		Name: "jsonMultiPathRoute",
+		Doc:  "is the JSON representation of a next hop of a multipath route produced by networkd.",
+	},
+	"Neighbors": {
+		Name: "jsonNeighbor",
+		Doc:  "is the JSON representation of a neighbor produced by networkd.",
+	},
+	"NextHops": {
+		Name: "jsonNextHop",
+		Doc:  "is the JSON representation of a next hop produced by networkd.",
+	},
+	"NTP": {
+		Name: "jsonNTP",
+		Doc:  "is the JSON representation of an NTP server produced by networkd.",
+	},
+	"Prefixes": {
+		Name: "jsonDHCPv6Prefix",
+		Doc:  "is the JSON representation of a delegated DHCPv6 prefix.",
+	},
+	"PrivateOptions": {
+		Name: "jsonDHCPOption",
+		Doc:  "is the JSON representation of a DHCP option.",
+	},
+	"RouteDomains": {
+		Name: "jsonDomain",
+		Doc:  "is the JSON representation of a DNS domain produced by networkd.",
+	},
+	"Routes": {
+		Name: "jsonRoute",
+		Doc:  "is the JSON representation of a route produced by networkd.",
+	},
+	"RoutingPolicyRules": {
+		Name: "jsonRoutingPolicyRule",
+		Doc:  "is the JSON representation of a routing policy rule produced by networkd.",
+	},
+	"SearchDomains": {
+		Name: "jsonDomain",
+		Doc:  "is the JSON representation of a DNS domain produced by networkd.",
+	},
+	"StaticLeases": {
+		Name: "jsonDHCPServerStaticLease",
+		Doc:  "is the JSON representation of a static lease configured for networkd's DHCP server.",
+	},
+}
+
+// Go types for JSON values which are not decoded using the default types.
+var (
+	// numbers maps keys to the types of JSON numbers. Keys ending in USec
+	// are uint64, and all others are int.
+	numbers = map[string]string{
+		"EnabledCapabilities": "uint16",
+		"FirewallMark":        "uint32",
+		"FirewallMask":        "uint32",
+		"Flags":               "uint32",
+		"IAID":                "uint32",
+		"ID":                  "uint32",
+		"NamespaceId":         "uint64",
+		"NamespaceNSID":       "uint32",
+		"Option":              "uint8",
+		"Port":                "uint16",
+		"Priority":            "uint32",
+		"Table":               "uint32",
+		"VLANID":              "uint16",
+		"Weight":              "uint32",
+	}
+
+	// strs maps keys to the types of JSON strings, and of arrays of strings.
+	strs = map[string]string{
+		"AddressState":                      "AddressState",
+		"AdministrativeState":               "AdministrativeState",
+		"CarrierState":                      "CarrierState",
+		"ConfigSource":                      "ConfigSource",
+		"IPv4AddressState":                  "AddressState",
+		"IPv6AddressState":                  "AddressState",
+		"OnlineState":                       "OnlineState",
+		"OperationalState":                  "OperationalState",
+		"RequiredFamilyForOnline":           "AddressFamily",
+		"RequiredOperationalStateForOnline": "OperationalState",
+	}
+
+	// addrs are the keys of arrays of numbers which are IP addresses. All
+	// other arrays of numbers are decoded as bytes.
+	addrs = map[string]bool{
+		"Address":              true,
+		"Broadcast":            true,
+		"ConfigProvider":       true,
+		"Destination":          true,
+		"FromPrefix":           true,
+		"Gateway":              true,
+		"IPv6LinkLocalAddress": true,
+		"PreferredSource":      true,
+		"Prefix":               true,
+		"ToPrefix":             true,
+	}
+
+	// names maps keys to Go field names which differ from the key.
+	names = map[string]string{
+		"ClientId":    "ClientID",
+		"NamespaceId": "NamespaceID",
+	}
+
+	// aliases maps keys spelled differently by some releases to the key used
+	// by others. encoding/json matches keys case-insensitively, so both
+	// spellings decode into the same field.
+	aliases = map[string]string{
+		"PreferredLifetimeUsec": "PreferredLifetimeUSec",
+		"ValidLifetimeUsec":     "ValidLifetimeUSec",
+	}
+)
+
+func main() {
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Parse()
+
+	var files []string
+	for _, arg := range flag.Args() {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			log.Fatalf("invalid fixture pattern %q: %v", arg, err)
+		}
+
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		log.Fatal("no fixture files specified")
+	}
+
+	b, err := generate(files)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		_, _ = os.Stdout.Write(b)
+		return
+	}
+
+	if err := os.WriteFile(*out, b, 0o644); err != nil {
+		log.Fatalf("failed to write output: %v", err)
+	}
+}
+
+// A structType accumulates the fields of a generated struct type.
+type structType struct {
+	spec   typeSpec
+	fields map[string]string
+}
+
+// A generator infers struct types from JSON fixtures.
+type generator struct {
+	types map[string]*structType
+}
+
+// generate produces formatted Go source for the types inferred from the JSON
+// fixtures in files.
+func generate(files []string) ([]byte, error) {
+	g := &generator{types: make(map[string]*structType)}
+
+	bases := make([]string, 0, len(files))
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %v", err)
+		}
+
+		var obj map[string]any
+		if err := json.Unmarshal(b, &obj); err != nil {
+			return nil, fmt.Errorf("failed to decode fixture %q: %v", f, err)
+		}
+
+		// Only the Manager description contains the list of links.
+		spec := linkSpec
+		if _, ok := obj["Interfaces"]; ok {
+			spec = managerSpec
+		}
+
+		if err := g.object(spec, obj); err != nil {
+			return nil, fmt.Errorf("fixture %q: %v", f, err)
+		}
+
+		bases = append(bases, filepath.Base(f))
+	}
+
+	sort.Strings(bases)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by describegen from %s; DO NOT EDIT.\n\n", strings.Join(bases, ", "))
+	buf.WriteString("package networkd\n")
+
+	types := make([]string, 0, len(g.types))
+	for name := range g.types {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+
+	for _, name := range types {
+		st := g.types[name]
+
+		keys := make([]string, 0, len(st.fields))
+		for k := range st.fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf.WriteString("\n")
+		buf.WriteString(comment(fmt.Sprintf("A %s %s", st.spec.Name, st.spec.Doc)))
+		fmt.Fprintf(&buf, "type %s struct {\n", st.spec.Name)
+		for _, k := range keys {
+			if n, ok := names[k]; ok {
+				fmt.Fprintf(&buf, "%s %s `json:%q`\n", n, st.fields[k], k)
+				continue
+			}
+
+			fmt.Fprintf(&buf, "%s %s\n", k, st.fields[k])
+		}
+		buf.WriteString("}\n")
+	}
+
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format output: %v", err)
+	}
+
+	return b, nil
+}
+
+// comment formats text as a Go comment wrapped at 80 columns.
+func comment(text string) string {
+	var (
+		b    strings.Builder
+		line = "//"
+	)
+
+	for _, w := range strings.Fields(text) {
+		if len(line)+1+len(w) > 80 {
+			b.WriteString(line + "\n")
+			line = "//"
+		}
+
+		line += " " + w
+	}
+
+	b.WriteString(line + "\n")
+	return b.String()
+}
+
+// object adds the fields of the JSON object obj to the type specified by spec.
+func (g *generator) object(spec typeSpec, obj map[string]any) error {
+	st, ok := g.types[spec.Name]
+	if !ok {
+		st = &structType{spec: spec, fields: make(map[string]string)}
+		g.types[spec.Name] = st
+	}
+
+	for k, v := range obj {
+		if a, ok := aliases[k]; ok {
+			k = a
+		}
+
+		typ, err := g.value(k, v)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %v", spec.Name, k, err)
+		}
+		if typ == "" {
+			// Type cannot be inferred from this value.
+			continue
+		}
+
+		if prev, ok := st.fields[k]; ok && prev != typ {
+			return fmt.Errorf("%s.%s: conflicting types %s and %s", spec.Name, k, prev, typ)
+		}
+
+		st.fields[k] = typ
+	}
+
+	return nil
+}
+
+// value infers the Go type of the JSON value v with key k, adding the fields
+// of any nested objects to their types. It returns an empty string if the type
+// cannot be inferred.
+func (g *generator) value(k string, v any) (string, error) {
+	switch v := v.(type) {
+	case bool:
+		return "bool", nil
+	case string:
+		if t, ok := strs[k]; ok {
+			return t, nil
+		}
+		return "string", nil
+	case float64:
+		if t, ok := numbers[k]; ok {
+			return t, nil
+		}
+		if strings.HasSuffix(k, "USec") {
+			return "uint64", nil
+		}
+		return "int", nil
+	case map[string]any:
+		spec, ok := objects[k]
+		if !ok {
+			return "", nil
+		}
+		if err := g.object(spec, v); err != nil {
+			return "", err
+		}
+		return "*" + spec.ref(), nil
+	case []any:
+		return g.array(k, v)
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unhandled JSON value type %T", v)
+	}
+}
+
+// array infers the Go type of the JSON array vs with key k.
+func (g *generator) array(k string, vs []any) (string, error) {
+	if len(vs) == 0 {
+		return "", nil
+	}
+
+	switch vs[0].(type) {
+	case float64:
+		if addrs[k] {
+			return "jsonAddr", nil
+		}
+		return "jsonBytes", nil
+	case string:
+		if t, ok := strs[k]; ok {
+			return "[]" + t, nil
+		}
+		return "[]string", nil
+	case map[string]any:
+		spec, ok := objects[k]
+		if !ok {
+			return "", nil
+		}
+
+		for _, v := range vs {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return "", fmt.Errorf("mixed array element types")
+			}
+			if err := g.object(spec, obj); err != nil {
+				return "", err
+			}
+		}
+		return "[]" + spec.ref(), nil
+	default:
+		return "", fmt.Errorf("unhandled JSON array element type %T", vs[0])
+	}
+}
+
+// ref returns the name used to refer to the type specified by s.
+func (s typeSpec) ref() string {
+	if s.Ref != "" {
+		return s.Ref
+	}
+
+	return s.Name
+}
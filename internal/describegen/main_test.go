@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateUpToDate(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/*.json")
+	if err != nil {
+		t.Fatalf("failed to find fixtures: %v", err)
+	}
+
+	got, err := generate(files)
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+
+	want, err := os.ReadFile("../../zdescribe.go")
+	if err != nil {
+		t.Fatalf("failed to read generated file: %v", err)
+	}
+
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Fatalf("zdescribe.go is out of date, run go generate (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerateConflict(t *testing.T) {
+	// A field with different JSON types across fixtures cannot be decoded.
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.json")
+	b := filepath.Join(dir, "b.json")
+
+	if err := os.WriteFile(a, []byte(`{"Index":1}`), 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	if err := os.WriteFile(b, []byte(`{"Index":"1"}`), 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	if _, err := generate([]string{a, b}); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestGenerateAlias(t *testing.T) {
+	// Older releases spell some keys differently, which must produce a single
+	// field.
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.json")
+	b := filepath.Join(dir, "b.json")
+
+	if err := os.WriteFile(a, []byte(`{"Addresses":[{"ValidLifetimeUSec":1}]}`), 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	if err := os.WriteFile(b, []byte(`{"Addresses":[{"ValidLifetimeUsec":1}]}`), 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	got, err := generate([]string{a, b})
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+
+	if n := strings.Count(string(got), "ValidLifetime"); n != 1 {
+		t.Fatalf("expected one ValidLifetime field, but got %d:\n%s", n, got)
+	}
+}
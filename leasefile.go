@@ -0,0 +1,206 @@
+package networkd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// leaseDir is the directory in which networkd stores DHCPv4 client leases.
+const leaseDir = "/run/systemd/netif/leases"
+
+// ReadDHCPLeases reads the DHCPv4 client leases stored by networkd in dir, as a
+// fallback for systems on which the leases are not available over D-Bus. If dir
+// is empty, networkd's default of /run/systemd/netif/leases is used. The
+// returned map is keyed by interface index.
+//
+// networkd considers these files private, so their format may change between
+// releases. Fields which cannot be parsed are left unset. The lease times are
+// relative to each file's modification time, as networkd rewrites a lease file
+// whenever its lease is acquired or renewed.
+func ReadDHCPLeases(dir string) (map[int]*DHCPLease, error) {
+	if dir == "" {
+		dir = leaseDir
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read DHCP leases: %w", err)
+	}
+
+	leases := make(map[int]*DHCPLease, len(entries))
+	for _, e := range entries {
+		// Each lease file is named after its interface index.
+		index, err := strconv.Atoi(e.Name())
+		if err != nil || !e.Type().IsRegular() {
+			continue
+		}
+
+		l, err := readDHCPLease(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		leases[index] = l
+	}
+
+	return leases, nil
+}
+
+// readDHCPLease reads a single DHCPv4 lease file.
+func readDHCPLease(file string) (*DHCPLease, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("read DHCP lease: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("read DHCP lease: %w", err)
+	}
+
+	l, err := ParseDHCPLease(f, fi.ModTime())
+	if err != nil {
+		return nil, fmt.Errorf("parse DHCP lease %q: %w", file, err)
+	}
+
+	return l, nil
+}
+
+// ParseDHCPLease parses a DHCPv4 lease in the environment file format which
+// networkd uses to store leases in /run/systemd/netif/leases. The file stores
+// lease times relative to the time the lease was acquired, which must be
+// provided as acquired.
+func ParseDHCPLease(r io.Reader, acquired time.Time) (*DHCPLease, error) {
+	var (
+		l    DHCPLease
+		addr netip.Addr
+		mask netip.Addr
+	)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed lease line: %q", line)
+		}
+		v = strings.Trim(v, `"`)
+
+		switch k {
+		case "ADDRESS":
+			addr, _ = netip.ParseAddr(v)
+		case "NETMASK":
+			mask, _ = netip.ParseAddr(v)
+		case "SERVER_ADDRESS":
+			l.Server, _ = netip.ParseAddr(v)
+		case "ROUTER":
+			// Newer versions of networkd store a list of routers.
+			f, _, _ := strings.Cut(v, " ")
+			l.Router, _ = netip.ParseAddr(f)
+		case "NEXT_SERVER":
+			l.NextServer, _ = netip.ParseAddr(v)
+		case "ROOT_PATH":
+			l.RootPath = v
+		case "STATIC_ROUTES":
+			l.StaticRoutes = leaseRoutes(v)
+		case "CLASSLESS_ROUTES":
+			l.ClasslessRoutes = leaseRoutes(v)
+		case "VENDOR_SPECIFIC":
+			if b, err := hex.DecodeString(v); err == nil {
+				l.VendorSpecific = b
+			}
+		case "LIFETIME":
+			l.Expires = leaseTime(acquired, v)
+		case "T1":
+			l.T1 = leaseTime(acquired, v)
+		case "T2":
+			l.T2 = leaseTime(acquired, v)
+		default:
+			code, ok := strings.CutPrefix(k, "OPTION_")
+			if !ok {
+				continue
+			}
+
+			c, err := strconv.ParseUint(code, 10, 8)
+			if err != nil {
+				continue
+			}
+
+			b, err := hex.DecodeString(v)
+			if err != nil {
+				continue
+			}
+
+			l.PrivateOptions = append(l.PrivateOptions, DHCPOption{Code: uint8(c), Data: b})
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if addr.Is4() {
+		bits := 32
+		if mask.Is4() {
+			m := mask.As4()
+			bits, _ = net.IPMask(m[:]).Size()
+		}
+
+		l.Address = netip.PrefixFrom(addr, bits)
+	}
+
+	return &l, nil
+}
+
+// leaseRoutes parses a space-separated list of routes in the form
+// destination/length,gateway. Invalid routes are skipped.
+func leaseRoutes(s string) []DHCPRoute {
+	var routes []DHCPRoute
+	for _, f := range strings.Fields(s) {
+		dst, gw, ok := strings.Cut(f, ",")
+		if !ok {
+			continue
+		}
+
+		var (
+			r   DHCPRoute
+			err error
+		)
+		if r.Destination, err = netip.ParsePrefix(dst); err != nil {
+			continue
+		}
+		if r.Gateway, err = netip.ParseAddr(gw); err != nil {
+			continue
+		}
+
+		routes = append(routes, r)
+	}
+
+	return routes
+}
+
+// leaseTime parses a lease duration in seconds and returns the time that
+// duration after acquired. Invalid and infinite durations produce the zero
+// time.
+func leaseTime(acquired time.Time, s string) time.Time {
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n == math.MaxUint32 {
+		return time.Time{}
+	}
+
+	return acquired.Add(time.Duration(n) * time.Second)
+}
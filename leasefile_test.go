@@ -0,0 +1,164 @@
+package networkd
+
+import (
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testLeaseFile = `# This is private data. Do not parse.
+ADDRESS=192.168.1.100
+NETMASK=255.255.255.0
+ROUTER=192.168.1.1 192.168.1.2
+SERVER_ADDRESS=192.168.1.1
+T1=1800
+T2=3150
+LIFETIME=3600
+DNS=192.168.1.1
+DOMAINNAME=example.com
+CLIENTID=ff52540000000a
+NEXT_SERVER=192.168.1.5
+ROOT_PATH=/srv/root
+STATIC_ROUTES=10.0.0.0/8,192.168.1.2
+CLASSLESS_ROUTES=0.0.0.0/0,192.168.1.1 172.16.0.0/12,192.168.1.3 bogus
+VENDOR_SPECIFIC=0102cafe
+OPTION_224=cafe
+`
+
+func TestParseDHCPLease(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		l    *DHCPLease
+		ok   bool
+	}{
+		{
+			name: "malformed",
+			s:    "ADDRESS",
+		},
+		{
+			name: "malformed options",
+			s:    "ADDRESS=192.168.1.100\nVENDOR_SPECIFIC=zz\nOPTION_224=zz\nOPTION_225=cafe\n",
+			l: &DHCPLease{
+				Address:        netip.MustParsePrefix("192.168.1.100/32"),
+				PrivateOptions: []DHCPOption{{Code: 225, Data: []byte{0xca, 0xfe}}},
+			},
+			ok: true,
+		},
+		{
+			name: "empty",
+			l:    &DHCPLease{},
+			ok:   true,
+		},
+		{
+			name: "infinite",
+			s:    "ADDRESS=192.168.1.100\nLIFETIME=4294967295\n",
+			l:    &DHCPLease{Address: netip.MustParsePrefix("192.168.1.100/32")},
+			ok:   true,
+		},
+		{
+			name: "OK",
+			s:    testLeaseFile,
+			l: &DHCPLease{
+				Address: netip.MustParsePrefix("192.168.1.100/24"),
+				Server:  netip.MustParseAddr("192.168.1.1"),
+				Router:  netip.MustParseAddr("192.168.1.1"),
+				Expires: time.Unix(4600, 0),
+				T1:      time.Unix(2800, 0),
+				T2:      time.Unix(4150, 0),
+				PrivateOptions: []DHCPOption{{
+					Code: 224,
+					Data: []byte{0xca, 0xfe},
+				}},
+				StaticRoutes: []DHCPRoute{{
+					Destination: netip.MustParsePrefix("10.0.0.0/8"),
+					Gateway:     netip.MustParseAddr("192.168.1.2"),
+				}},
+				ClasslessRoutes: []DHCPRoute{
+					{
+						Destination: netip.MustParsePrefix("0.0.0.0/0"),
+						Gateway:     netip.MustParseAddr("192.168.1.1"),
+					},
+					{
+						Destination: netip.MustParsePrefix("172.16.0.0/12"),
+						Gateway:     netip.MustParseAddr("192.168.1.3"),
+					},
+				},
+				NextServer:     netip.MustParseAddr("192.168.1.5"),
+				RootPath:       "/srv/root",
+				VendorSpecific: []byte{0x01, 0x02, 0xca, 0xfe},
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := ParseDHCPLease(strings.NewReader(tt.s), time.Unix(1000, 0))
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse lease: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if diff := cmp.Diff(tt.l, l, cmp.Comparer(addrEqual), cmp.Comparer(prefixEqual)); diff != "" {
+				t.Fatalf("unexpected lease (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadDHCPLeases(t *testing.T) {
+	dir := t.TempDir()
+
+	// Only files named after interface indices are leases.
+	for file, s := range map[string]string{
+		"2":      testLeaseFile,
+		"3":      "ADDRESS=10.0.0.2\nNETMASK=255.0.0.0\n",
+		"README": "not a lease",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(s), 0o644); err != nil {
+			t.Fatalf("failed to write lease: %v", err)
+		}
+	}
+
+	// Lease times are relative to the time the file was last written.
+	acquired := time.Unix(1000, 0)
+	if err := os.Chtimes(filepath.Join(dir, "2"), acquired, acquired); err != nil {
+		t.Fatalf("failed to set lease modification time: %v", err)
+	}
+
+	leases, err := ReadDHCPLeases(dir)
+	if err != nil {
+		t.Fatalf("failed to read leases: %v", err)
+	}
+
+	got := make(map[int]netip.Prefix, len(leases))
+	for index, l := range leases {
+		got[index] = l.Address
+	}
+
+	want := map[int]netip.Prefix{
+		2: netip.MustParsePrefix("192.168.1.100/24"),
+		3: netip.MustParsePrefix("10.0.0.2/8"),
+	}
+
+	if diff := cmp.Diff(want, got, cmp.Comparer(prefixEqual)); diff != "" {
+		t.Fatalf("unexpected leases (-want +got):\n%s", diff)
+	}
+
+	if want, got := time.Unix(4600, 0), leases[2].Expires; !got.Equal(want) {
+		t.Fatalf("unexpected lease expiry: want %v, got %v", want, got)
+	}
+
+	if _, err := ReadDHCPLeases(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, but got: %v", err)
+	}
+}
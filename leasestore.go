@@ -0,0 +1,102 @@
+package networkd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// leaseStoreDir is the directory in which networkd persists the leases handed
+// out by its DHCP server.
+const leaseStoreDir = "/var/lib/systemd/network/dhcp-server-lease"
+
+// ReadStoredDHCPServerLeases reads the DHCP server leases which networkd
+// persists across reboots in dir. If dir is empty, networkd's default of
+// /var/lib/systemd/network/dhcp-server-lease is used. The returned map is
+// keyed by interface name.
+//
+// Only newer versions of networkd persist DHCP server leases. Because the
+// leases are persisted across reboots, their expiration times are based on
+// the wall clock rather than CLOCK_BOOTTIME, and leases which have already
+// expired may be included.
+func ReadStoredDHCPServerLeases(dir string) (map[string][]DHCPServerLease, error) {
+	if dir == "" {
+		dir = leaseStoreDir
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read stored DHCP server leases: %w", err)
+	}
+
+	leases := make(map[string][]DHCPServerLease, len(entries))
+	for _, e := range entries {
+		// Each lease file is named after its interface.
+		if !e.Type().IsRegular() {
+			continue
+		}
+
+		ls, err := readStoredDHCPServerLeases(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		leases[e.Name()] = ls
+	}
+
+	return leases, nil
+}
+
+// readStoredDHCPServerLeases reads a single DHCP server lease file.
+func readStoredDHCPServerLeases(file string) ([]DHCPServerLease, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("read stored DHCP server leases: %w", err)
+	}
+	defer f.Close()
+
+	ls, err := ParseStoredDHCPServerLeases(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse stored DHCP server leases %q: %w", file, err)
+	}
+
+	return ls, nil
+}
+
+// A jsonDHCPServerLeaseStore is the JSON representation of the DHCP server
+// leases persisted by networkd for a link.
+type jsonDHCPServerLeaseStore struct {
+	Address      jsonAddr
+	PrefixLength int
+	Leases       []jsonDHCPServerLease
+}
+
+// ParseStoredDHCPServerLeases parses the JSON format in which networkd persists
+// the leases handed out by its DHCP server on a link.
+func ParseStoredDHCPServerLeases(r io.Reader) ([]DHCPServerLease, error) {
+	var js jsonDHCPServerLeaseStore
+	if err := json.NewDecoder(r).Decode(&js); err != nil {
+		return nil, err
+	}
+
+	leases := make([]DHCPServerLease, 0, len(js.Leases))
+	for _, l := range js.Leases {
+		sl := DHCPServerLease{
+			ClientID:     l.ClientID,
+			Address:      netip.Addr(l.Address),
+			HardwareAddr: hardwareAddr(l.HardwareAddress),
+		}
+		if us := l.ExpirationRealtimeUSec; us != 0 && us != math.MaxUint64 {
+			sl.Expires = time.UnixMicro(int64(us))
+		}
+
+		leases = append(leases, sl)
+	}
+
+	return leases, nil
+}
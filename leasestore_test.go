@@ -0,0 +1,80 @@
+package networkd
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testLeaseStore = `{
+	"Address": [192, 168, 1, 1],
+	"PrefixLength": 24,
+	"Leases": [
+		{
+			"ClientId": [1, 82, 84, 0, 0, 0, 10],
+			"Address": [192, 168, 1, 110],
+			"Hostname": "printer",
+			"HardwareAddressType": 1,
+			"HardwareAddressLength": 6,
+			"HardwareAddress": [82, 84, 0, 0, 0, 10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0],
+			"ExpirationRealtimeUSec": 1700003600000000
+		}
+	]
+}`
+
+func TestParseStoredDHCPServerLeases(t *testing.T) {
+	leases, err := ParseStoredDHCPServerLeases(strings.NewReader(testLeaseStore))
+	if err != nil {
+		t.Fatalf("failed to parse leases: %v", err)
+	}
+
+	want := []DHCPServerLease{{
+		ClientID:     []byte{1, 0x52, 0x54, 0, 0, 0, 0x0a},
+		Address:      netip.MustParseAddr("192.168.1.110"),
+		HardwareAddr: net.HardwareAddr{0x52, 0x54, 0, 0, 0, 0x0a},
+		Expires:      time.Unix(1700003600, 0),
+	}}
+
+	if diff := cmp.Diff(want, leases, cmp.Comparer(addrEqual)); diff != "" {
+		t.Fatalf("unexpected leases (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseStoredDHCPServerLeases(strings.NewReader("{")); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestReadStoredDHCPServerLeases(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "eth0"), []byte(testLeaseStore), 0o644); err != nil {
+		t.Fatalf("failed to write leases: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "eth1"), []byte(`{"Leases":[]}`), 0o644); err != nil {
+		t.Fatalf("failed to write leases: %v", err)
+	}
+
+	leases, err := ReadStoredDHCPServerLeases(dir)
+	if err != nil {
+		t.Fatalf("failed to read leases: %v", err)
+	}
+
+	got := make(map[string]int, len(leases))
+	for name, ls := range leases {
+		got[name] = len(ls)
+	}
+
+	if diff := cmp.Diff(map[string]int{"eth0": 1, "eth1": 0}, got); diff != "" {
+		t.Fatalf("unexpected leases (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadStoredDHCPServerLeases(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, but got: %v", err)
+	}
+}
@@ -0,0 +1,654 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/mdlayher/networkd/config"
+)
+
+// Address family values used by networkd's D-Bus API.
+const (
+	afINET  = 2
+	afINET6 = 10
+)
+
+// A LinkService exposes methods and properties of a single networkd Link
+// object.
+type LinkService struct {
+	c *Client
+	l Link
+}
+
+// Link returns a LinkService which operates on the networkd Link object for l.
+// l is typically produced by ManagerService.ListLinks.
+func (c *Client) Link(l Link) *LinkService {
+	return &LinkService{c: c, l: l}
+}
+
+// Link returns the Link which this LinkService operates on.
+func (ls *LinkService) Link() Link { return ls.l }
+
+// LinkProperties contains all of the D-Bus properties for a networkd Link
+// object.
+type LinkProperties struct {
+	OperationalState    OperationalState
+	CarrierState        CarrierState
+	AddressState        AddressState
+	IPv4AddressState    AddressState
+	IPv6AddressState    AddressState
+	OnlineState         OnlineState
+	AdministrativeState AdministrativeState
+
+	// TxBitRate and RxBitRate are networkd's estimates of the link's transmit
+	// and receive speeds in bits per second. Both are set to math.MaxUint64
+	// if networkd's speed meter is not enabled.
+	TxBitRate, RxBitRate uint64
+
+	// Raw contains all of the unprocessed D-Bus properties, including any
+	// which are not yet exposed by this package.
+	Raw map[string]dbus.Variant
+}
+
+// Properties fetches all D-Bus properties for this link's networkd Link object.
+func (ls *LinkService) Properties(ctx context.Context) (LinkProperties, error) {
+	out, err := ls.c.getAll(ctx, ls.l.ObjectPath, interfacePath("Link"))
+	if err != nil {
+		return LinkProperties{}, err
+	}
+
+	return parseLinkProperties(out)
+}
+
+// parseLinkProperties unpacks LinkProperties from a D-Bus property map.
+// Properties which are missing, such as those only reported by newer versions
+// of networkd, are left unset.
+func parseLinkProperties(out map[string]dbus.Variant) (LinkProperties, error) {
+	props := LinkProperties{Raw: out}
+
+	for _, p := range []struct {
+		name string
+		s    *string
+	}{
+		{"OperationalState", (*string)(&props.OperationalState)},
+		{"CarrierState", (*string)(&props.CarrierState)},
+		{"AddressState", (*string)(&props.AddressState)},
+		{"IPv4AddressState", (*string)(&props.IPv4AddressState)},
+		{"IPv6AddressState", (*string)(&props.IPv6AddressState)},
+		{"OnlineState", (*string)(&props.OnlineState)},
+		{"AdministrativeState", (*string)(&props.AdministrativeState)},
+	} {
+		if err := propertyString(out, p.name, p.s); err != nil {
+			return LinkProperties{}, err
+		}
+	}
+
+	// BitRates is a (tt) structure of transmit and receive speeds.
+	if v, ok := out["BitRates"]; ok {
+		rates, ok := v.Value().([]any)
+		if !ok || len(rates) != 2 {
+			return LinkProperties{}, fmt.Errorf("networkd: invalid bit rates value: %v", v)
+		}
+
+		tx, txOK := rates[0].(uint64)
+		rx, rxOK := rates[1].(uint64)
+		if !txOK || !rxOK {
+			return LinkProperties{}, fmt.Errorf("networkd: invalid bit rates value: %v", v)
+		}
+
+		props.TxBitRate, props.RxBitRate = tx, rx
+	}
+
+	return props, nil
+}
+
+// propertyString stores the string D-Bus property name from out in s. If the
+// property does not exist, s is left unchanged.
+func propertyString(out map[string]dbus.Variant, name string, s *string) error {
+	v, ok := out[name]
+	if !ok {
+		return nil
+	}
+
+	str, ok := v.Value().(string)
+	if !ok {
+		return fmt.Errorf("networkd: unexpected type %T for property %q", v.Value(), name)
+	}
+
+	*s = str
+	return nil
+}
+
+// getString fetches a single string D-Bus property for the link.
+func (ls *LinkService) getString(ctx context.Context, prop string) (string, error) {
+	v, err := ls.c.get(ctx, ls.l.ObjectPath, interfacePath("Link"), prop)
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := v.Value().(string)
+	if !ok {
+		return "", fmt.Errorf("networkd: unexpected type %T for property %q", v.Value(), prop)
+	}
+
+	return s, nil
+}
+
+// IsManaged reports whether networkd manages this link. Links which networkd
+// is still evaluating, or which have disappeared, are not reported as managed.
+func (ls *LinkService) IsManaged(ctx context.Context) (bool, error) {
+	state, err := ls.getString(ctx, "AdministrativeState")
+	if err != nil {
+		return false, err
+	}
+
+	switch AdministrativeState(state) {
+	case AdministrativeConfiguring, AdministrativeConfigured, AdministrativeFailed:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// Ready reports whether the link is fully configured by networkd and has
+// reached at least the min operational state, using the same criteria as
+// systemd-networkd-wait-online applies to a single link.
+func (lp LinkProperties) Ready(min OperationalState) bool {
+	// Links which are still being processed by networkd are never ready.
+	switch lp.AdministrativeState {
+	case AdministrativeConfigured, AdministrativeUnmanaged:
+	default:
+		return false
+	}
+
+	if !lp.OperationalState.AtLeast(min) {
+		return false
+	}
+
+	// The operational state summarizes the carrier and address states, but
+	// verify them individually so that inconsistent combinations are not
+	// reported as ready.
+	if min.AtLeast(OperationalCarrier) && !lp.CarrierState.AtLeast(CarrierCarrier) {
+		return false
+	}
+
+	switch {
+	case min.AtLeast(OperationalRoutable):
+		return lp.AddressState.AtLeast(AddressRoutable)
+	case min.AtLeast(OperationalDegraded):
+		return lp.AddressState.AtLeast(AddressDegraded)
+	default:
+		return true
+	}
+}
+
+// Ready fetches the link's properties and reports whether the link is ready
+// according to LinkProperties.Ready.
+func (ls *LinkService) Ready(ctx context.Context, min OperationalState) (bool, error) {
+	lp, err := ls.Properties(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return lp.Ready(min), nil
+}
+
+// WaitForState blocks until the link reaches at least the min operational
+// state, or until ctx is canceled. WaitForState is driven by D-Bus
+// PropertiesChanged signals rather than polling.
+func (ls *LinkService) WaitForState(ctx context.Context, min OperationalState) error {
+	// Subscribe before checking the current state so that no state changes
+	// are missed in between.
+	signals, cancel, err := ls.c.watch(ctx,
+		dbus.WithMatchObjectPath(ls.l.ObjectPath),
+		dbus.WithMatchInterface(interfaceProperties),
+		dbus.WithMatchMember("PropertiesChanged"),
+	)
+	if err != nil {
+		return fmt.Errorf("watch link %q: %w", ls.l.Name, err)
+	}
+	defer cancel()
+
+	state, err := ls.getString(ctx, "OperationalState")
+	if err != nil {
+		return err
+	}
+	if OperationalState(state).AtLeast(min) {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for link %q to reach state %q: %w", ls.l.Name, min, ctx.Err())
+		case s, ok := <-signals:
+			if !ok {
+				return fmt.Errorf("wait for link %q to reach state %q: %w", ls.l.Name, min, errConnClosed)
+			}
+
+			changed, ok := propertiesChanged(s, ls.l.ObjectPath, interfacePath("Link"))
+			if !ok {
+				continue
+			}
+
+			v, ok := changed["OperationalState"]
+			if !ok {
+				continue
+			}
+
+			state, ok := v.Value().(string)
+			if !ok {
+				return fmt.Errorf("networkd: unexpected type %T for property %q", v.Value(), "OperationalState")
+			}
+			if OperationalState(state).AtLeast(min) {
+				return nil
+			}
+		}
+	}
+}
+
+// WaitBelowState blocks until the link's operational state drops below
+// threshold or the link is removed, or until ctx is canceled. It is the inverse
+// of WaitForState.
+func (ls *LinkService) WaitBelowState(ctx context.Context, threshold OperationalState) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	props, ch, err := ls.watchProperties(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Stop watching and wait for the subscription to end.
+		cancel()
+		for range ch {
+		}
+	}()
+
+	err = waitProperties(ctx, props, ch, func(lp LinkProperties) bool {
+		// Links which are being removed linger until networkd forgets them.
+		return lp.AdministrativeState == AdministrativeLinger || !lp.OperationalState.AtLeast(threshold)
+	})
+	if err != nil {
+		return fmt.Errorf("wait for link %q to drop below state %q: %w", ls.l.Name, threshold, err)
+	}
+
+	return nil
+}
+
+// SetNTP sets the runtime NTP servers for this link, overriding any servers
+// set by the link's configuration. To specify NTP servers by host name, use
+// SetNTPNames.
+func (ls *LinkService) SetNTP(ctx context.Context, servers []netip.Addr) error {
+	names := make([]string, 0, len(servers))
+	for _, s := range servers {
+		if !s.IsValid() {
+			return fmt.Errorf("networkd: invalid NTP server address: %q", s)
+		}
+
+		names = append(names, s.Unmap().String())
+	}
+
+	return ls.SetNTPNames(ctx, names)
+}
+
+// SetNTPNames sets the runtime NTP servers for this link by host name or IP
+// address string, overriding any servers set by the link's configuration.
+func (ls *LinkService) SetNTPNames(ctx context.Context, names []string) error {
+	if err := ls.call(ctx, "SetNTP", nil, names); err != nil {
+		return fmt.Errorf("set NTP servers for link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// SetDNS sets the runtime DNS servers for this link, overriding any servers
+// set by the link's configuration.
+func (ls *LinkService) SetDNS(ctx context.Context, servers []netip.Addr) error {
+	addrs := make([]dnsAddress, 0, len(servers))
+	for _, s := range servers {
+		a, err := newDNSAddress(s)
+		if err != nil {
+			return err
+		}
+
+		addrs = append(addrs, a)
+	}
+
+	if err := ls.call(ctx, "SetDNS", nil, addrs); err != nil {
+		return fmt.Errorf("set DNS servers for link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// A DNSServer is a DNS server address with optional port and server name
+// information, as used by LinkService.SetDNSEx.
+type DNSServer struct {
+	// Addr is the IP address of the DNS server.
+	Addr netip.Addr
+
+	// Port is the port of the DNS server. If zero, the default port is used.
+	Port uint16
+
+	// ServerName is the name used for DNS-over-TLS server name indication.
+	// If empty, no name is sent.
+	ServerName string
+}
+
+// SetDNSEx sets the runtime DNS servers for this link with extended port and
+// server name information, overriding any servers set by the link's
+// configuration.
+//
+// If networkd does not support the SetDNSEx method, SetDNSEx falls back to
+// SetDNS as long as none of the servers specify a Port or ServerName.
+func (ls *LinkService) SetDNSEx(ctx context.Context, servers []DNSServer) error {
+	addrs := make([]dnsAddressEx, 0, len(servers))
+	for _, s := range servers {
+		a, err := newDNSAddress(s.Addr)
+		if err != nil {
+			return err
+		}
+
+		addrs = append(addrs, dnsAddressEx{
+			Family:     a.Family,
+			Address:    a.Address,
+			Port:       s.Port,
+			ServerName: s.ServerName,
+		})
+	}
+
+	err := ls.call(ctx, "SetDNSEx", nil, addrs)
+	if err == nil {
+		return nil
+	}
+	if !isUnknownMethod(err) {
+		return fmt.Errorf("set extended DNS servers for link %q: %w", ls.l.Name, err)
+	}
+
+	// Older versions of networkd do not support SetDNSEx. Fall back to SetDNS
+	// only if doing so does not discard any of the caller's configuration.
+	ips := make([]netip.Addr, 0, len(servers))
+	for _, s := range servers {
+		if s.Port != 0 || s.ServerName != "" {
+			return fmt.Errorf("set extended DNS servers for link %q: %w", ls.l.Name, err)
+		}
+
+		ips = append(ips, s.Addr)
+	}
+
+	return ls.SetDNS(ctx, ips)
+}
+
+// A Domain is a DNS domain configured on a link.
+type Domain struct {
+	// Name is the domain name.
+	Name string
+
+	// RoutingOnly reports whether the domain is only used to route DNS
+	// queries to this link's servers, rather than also being used as a
+	// search domain.
+	RoutingOnly bool
+}
+
+// SetDomains sets the runtime search and routing-only DNS domains for this
+// link, overriding any domains set by the link's configuration.
+func (ls *LinkService) SetDomains(ctx context.Context, domains []Domain) error {
+	// Domain is marshaled directly as the D-Bus (sb) structure.
+	if err := ls.call(ctx, "SetDomains", nil, domains); err != nil {
+		return fmt.Errorf("set DNS domains for link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// An LLMNRMode is a Link-Local Multicast Name Resolution mode for a link.
+type LLMNRMode string
+
+// Possible LLMNRMode values.
+const (
+	// LLMNRYes enables both LLMNR resolution and responding.
+	LLMNRYes LLMNRMode = "yes"
+
+	// LLMNRNo disables LLMNR.
+	LLMNRNo LLMNRMode = "no"
+
+	// LLMNRResolve enables LLMNR resolution only, without responding.
+	LLMNRResolve LLMNRMode = "resolve"
+)
+
+// SetLLMNR sets the runtime LLMNR mode for this link, overriding the mode set
+// by the link's configuration.
+func (ls *LinkService) SetLLMNR(ctx context.Context, mode LLMNRMode) error {
+	if err := ls.call(ctx, "SetLLMNR", nil, string(mode)); err != nil {
+		return fmt.Errorf("set LLMNR mode for link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// A DNSSECMode is a DNSSEC validation mode for a link.
+type DNSSECMode string
+
+// Possible DNSSECMode values.
+const (
+	// DNSSECYes enables strict DNSSEC validation.
+	DNSSECYes DNSSECMode = "yes"
+
+	// DNSSECNo disables DNSSEC validation.
+	DNSSECNo DNSSECMode = "no"
+
+	// DNSSECAllowDowngrade enables DNSSEC validation, but disables it if the
+	// link's DNS servers do not support DNSSEC.
+	DNSSECAllowDowngrade DNSSECMode = "allow-downgrade"
+)
+
+// SetDNSSEC sets the runtime DNSSEC mode for this link, overriding the mode
+// set by the link's configuration.
+func (ls *LinkService) SetDNSSEC(ctx context.Context, mode DNSSECMode) error {
+	if err := ls.call(ctx, "SetDNSSEC", nil, string(mode)); err != nil {
+		return fmt.Errorf("set DNSSEC mode for link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// SetDNSSECNegativeTrustAnchors replaces the runtime DNSSEC negative trust
+// anchors for this link. DNSSEC validation is not performed for the listed
+// domains.
+func (ls *LinkService) SetDNSSECNegativeTrustAnchors(ctx context.Context, domains []string) error {
+	if err := ls.call(ctx, "SetDNSSECNegativeTrustAnchors", nil, domains); err != nil {
+		return fmt.Errorf("set DNSSEC negative trust anchors for link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// Revert drops all runtime DNS and NTP configuration set on this link by the
+// Set* methods, restoring the settings from the link's configuration.
+func (ls *LinkService) Revert(ctx context.Context) error {
+	// networkd has no single method to revert all runtime configuration, so
+	// revert each of the settings in turn.
+	if err := ls.RevertNTP(ctx); err != nil {
+		return err
+	}
+
+	return ls.RevertDNS(ctx)
+}
+
+// RevertNTP drops the runtime NTP servers set on this link by SetNTP,
+// restoring the servers from the link's configuration.
+func (ls *LinkService) RevertNTP(ctx context.Context) error {
+	if err := ls.call(ctx, "RevertNTP", nil); err != nil {
+		return fmt.Errorf("revert NTP servers for link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// RevertDNS drops all runtime DNS configuration set on this link by the
+// SetDNS, SetDNSEx, SetDomains, SetLLMNR, SetDNSSEC, and
+// SetDNSSECNegativeTrustAnchors methods, restoring the settings from the
+// link's configuration.
+func (ls *LinkService) RevertDNS(ctx context.Context) error {
+	if err := ls.call(ctx, "RevertDNS", nil); err != nil {
+		return fmt.Errorf("revert DNS configuration for link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// Renew triggers a renewal of this link's DHCPv4 lease.
+func (ls *LinkService) Renew(ctx context.Context) error {
+	if err := ls.call(ctx, "Renew", nil); err != nil {
+		return fmt.Errorf("renew link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// ReconfigureOptions specifies options for LinkService.Reconfigure.
+type ReconfigureOptions struct {
+	// KeepConfiguration, if set, controls which of the link's existing
+	// addresses and routes networkd keeps rather than drops, using the values
+	// of the KeepConfiguration= setting: "yes", "static", "dhcp-on-stop",
+	// "dhcp", or "no". networkd's D-Bus API cannot set this for a single
+	// reconfiguration, so it is written to a drop-in for the link's .network
+	// file and networkd is reloaded before the link is reconfigured. The
+	// drop-in remains in effect until it is removed. If empty, the link's
+	// existing configuration is used.
+	KeepConfiguration string
+
+	// Dir is the directory in which the drop-in is written. If empty,
+	// /run/systemd/network is used, so that the drop-in does not persist
+	// across reboots.
+	Dir string
+}
+
+// keepConfigurationDropIn is the name of the drop-in written by Reconfigure.
+const keepConfigurationDropIn = "keep-configuration.conf"
+
+// Reconfigure reapplies the configuration for this link. This is typically
+// used after modifying the link's .network file and calling
+// ManagerService.Reload. If opts is nil, default options are used.
+func (ls *LinkService) Reconfigure(ctx context.Context, opts *ReconfigureOptions) error {
+	if opts == nil {
+		opts = &ReconfigureOptions{}
+	}
+	if opts.KeepConfiguration != "" {
+		if err := ls.keepConfiguration(ctx, opts); err != nil {
+			return fmt.Errorf("reconfigure link %q: %w", ls.l.Name, err)
+		}
+	}
+
+	if err := ls.call(ctx, "Reconfigure", nil); err != nil {
+		return fmt.Errorf("reconfigure link %q: %w", ls.l.Name, err)
+	}
+
+	return nil
+}
+
+// keepConfiguration writes a drop-in which sets KeepConfiguration= for the
+// link's .network file, and reloads networkd so that it takes effect.
+func (ls *LinkService) keepConfiguration(ctx context.Context, opts *ReconfigureOptions) error {
+	switch opts.KeepConfiguration {
+	case "yes", "static", "dhcp-on-stop", "dhcp", "no":
+	default:
+		return fmt.Errorf("invalid KeepConfiguration value %q", opts.KeepConfiguration)
+	}
+
+	b, err := ls.DescribeRaw(ctx)
+	if err != nil {
+		return err
+	}
+
+	jd, err := decodeLinkDescription(b)
+	if err != nil {
+		return err
+	}
+	if jd.NetworkFile == "" {
+		return errors.New("link is not configured by a .network file")
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = "/run/systemd/network"
+	}
+
+	// Only the file name matters, as networkd reads the drop-ins for a file
+	// from each of its configuration directories.
+	dropins := filepath.Join(dir, filepath.Base(jd.NetworkFile)+".d")
+	if err := os.MkdirAll(dropins, 0o755); err != nil {
+		return fmt.Errorf("create drop-in directory: %w", err)
+	}
+
+	n := &config.Network{Network: config.NetworkSection{
+		Extra: []config.Option{{Key: "KeepConfiguration", Value: opts.KeepConfiguration}},
+	}}
+	if err := config.WriteUnit(filepath.Join(dropins, keepConfigurationDropIn), n); err != nil {
+		return err
+	}
+
+	if err := ls.c.Manager.Reload(ctx); err != nil {
+		return fmt.Errorf("reload configuration: %w", err)
+	}
+
+	return nil
+}
+
+// DescribeRaw returns networkd's JSON description of this link. Use Describe
+// to decode the description.
+func (ls *LinkService) DescribeRaw(ctx context.Context) ([]byte, error) {
+	var s string
+	if err := ls.call(ctx, "Describe", &s); err != nil {
+		return nil, fmt.Errorf("describe link %q: %w", ls.l.Name, err)
+	}
+
+	return []byte(s), nil
+}
+
+// call calls a D-Bus method on the networkd Link interface for this link's
+// object.
+func (ls *LinkService) call(ctx context.Context, method string, out any, args ...any) error {
+	return ls.c.call(ctx, baseService, interfacePath("Link", method), ls.l.ObjectPath, out, args...)
+}
+
+// A dnsAddress is the D-Bus (iay) representation of a DNS server address.
+type dnsAddress struct {
+	Family  int32
+	Address []byte
+}
+
+// newDNSAddress converts ip to its D-Bus representation. IPv4-mapped IPv6
+// addresses are converted to IPv4 addresses.
+func newDNSAddress(ip netip.Addr) (dnsAddress, error) {
+	switch ip = ip.Unmap(); {
+	case ip.Is4():
+		return dnsAddress{Family: afINET, Address: ip.AsSlice()}, nil
+	case ip.Is6():
+		return dnsAddress{Family: afINET6, Address: ip.AsSlice()}, nil
+	default:
+		return dnsAddress{}, fmt.Errorf("networkd: invalid DNS server address: %q", ip)
+	}
+}
+
+// A dnsAddressEx is the D-Bus (iayqs) representation of a DNS server address
+// with port and server name.
+type dnsAddressEx struct {
+	Family     int32
+	Address    []byte
+	Port       uint16
+	ServerName string
+}
+
+// isUnknownMethod reports whether err indicates that a D-Bus method is not
+// implemented by the remote object.
+func isUnknownMethod(err error) bool {
+	var derr dbus.Error
+	return errors.As(err, &derr) && derr.Name == "org.freedesktop.DBus.Error.UnknownMethod"
+}
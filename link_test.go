@@ -0,0 +1,636 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// testLink is the Link used for LinkService unit tests.
+var testLink = Link{
+	Index:      2,
+	Name:       "eth0",
+	ObjectPath: objectPath("link", "_32"),
+}
+
+func TestLinkServiceSetNTP(t *testing.T) {
+	ls := testLinkService(t, "SetNTP", []any{[]string{"192.0.2.1", "2001:db8::1"}}, nil)
+
+	err := ls.SetNTP(context.Background(), []netip.Addr{
+		netip.MustParseAddr("::ffff:192.0.2.1"),
+		netip.MustParseAddr("2001:db8::1"),
+	})
+	if err != nil {
+		t.Fatalf("failed to set NTP: %v", err)
+	}
+
+	if err := ls.SetNTP(context.Background(), []netip.Addr{{}}); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestLinkServiceSetNTPNames(t *testing.T) {
+	ls := testLinkService(t, "SetNTP", []any{[]string{"time.example.com", "192.0.2.1"}}, nil)
+
+	if err := ls.SetNTPNames(context.Background(), []string{"time.example.com", "192.0.2.1"}); err != nil {
+		t.Fatalf("failed to set NTP: %v", err)
+	}
+}
+
+func TestLinkServiceSetDNS(t *testing.T) {
+	ls := testLinkService(t, "SetDNS", []any{[]dnsAddress{
+		{Family: afINET, Address: []byte{192, 0, 2, 1}},
+		{Family: afINET6, Address: netip.MustParseAddr("2001:db8::1").AsSlice()},
+	}}, nil)
+
+	err := ls.SetDNS(context.Background(), []netip.Addr{
+		netip.MustParseAddr("::ffff:192.0.2.1"),
+		netip.MustParseAddr("2001:db8::1"),
+	})
+	if err != nil {
+		t.Fatalf("failed to set DNS: %v", err)
+	}
+}
+
+func TestLinkServiceSetDNSInvalid(t *testing.T) {
+	ls := testLinkService(t, "SetDNS", nil, nil)
+
+	if err := ls.SetDNS(context.Background(), []netip.Addr{{}}); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestLinkServiceSetDNSEx(t *testing.T) {
+	ls := testLinkService(t, "SetDNSEx", []any{[]dnsAddressEx{
+		{Family: afINET, Address: []byte{192, 0, 2, 1}, Port: 853, ServerName: "dns.example.com"},
+		{Family: afINET6, Address: netip.MustParseAddr("2001:db8::1").AsSlice()},
+	}}, nil)
+
+	err := ls.SetDNSEx(context.Background(), []DNSServer{
+		{Addr: netip.MustParseAddr("192.0.2.1"), Port: 853, ServerName: "dns.example.com"},
+		{Addr: netip.MustParseAddr("2001:db8::1")},
+	})
+	if err != nil {
+		t.Fatalf("failed to set extended DNS: %v", err)
+	}
+}
+
+func TestLinkServiceSetDNSExFallback(t *testing.T) {
+	var methods []string
+	c := &Client{
+		call: func(_ context.Context, _, method string, _ dbus.ObjectPath, _ any, _ ...any) error {
+			methods = append(methods, method)
+			if method == interfacePath("Link", "SetDNSEx") {
+				return dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod"}
+			}
+
+			return nil
+		},
+	}
+
+	ls := c.Link(testLink)
+	if err := ls.SetDNSEx(context.Background(), []DNSServer{{Addr: netip.MustParseAddr("192.0.2.1")}}); err != nil {
+		t.Fatalf("failed to set extended DNS: %v", err)
+	}
+
+	want := []string{interfacePath("Link", "SetDNSEx"), interfacePath("Link", "SetDNS")}
+	if diff := cmp.Diff(want, methods); diff != "" {
+		t.Fatalf("unexpected methods (-want +got):\n%s", diff)
+	}
+
+	// Fallback is not possible when a server name is set.
+	methods = nil
+	if err := ls.SetDNSEx(context.Background(), []DNSServer{{Addr: netip.MustParseAddr("192.0.2.1"), ServerName: "dns.example.com"}}); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+	if diff := cmp.Diff(want[:1], methods); diff != "" {
+		t.Fatalf("unexpected methods (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkServiceSetDomains(t *testing.T) {
+	ls := testLinkService(t, "SetDomains", []any{[]Domain{
+		{Name: "example.com"},
+		{Name: "corp.example.com", RoutingOnly: true},
+	}}, nil)
+
+	err := ls.SetDomains(context.Background(), []Domain{
+		{Name: "example.com"},
+		{Name: "corp.example.com", RoutingOnly: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to set domains: %v", err)
+	}
+}
+
+func TestLinkServiceSetLLMNR(t *testing.T) {
+	ls := testLinkService(t, "SetLLMNR", []any{"resolve"}, nil)
+
+	if err := ls.SetLLMNR(context.Background(), LLMNRResolve); err != nil {
+		t.Fatalf("failed to set LLMNR: %v", err)
+	}
+}
+
+func TestLinkServiceSetDNSSEC(t *testing.T) {
+	ls := testLinkService(t, "SetDNSSEC", []any{"allow-downgrade"}, nil)
+
+	if err := ls.SetDNSSEC(context.Background(), DNSSECAllowDowngrade); err != nil {
+		t.Fatalf("failed to set DNSSEC: %v", err)
+	}
+}
+
+func TestLinkServiceSetDNSSECNegativeTrustAnchors(t *testing.T) {
+	ls := testLinkService(t, "SetDNSSECNegativeTrustAnchors", []any{[]string{"corp.example.com"}}, nil)
+
+	if err := ls.SetDNSSECNegativeTrustAnchors(context.Background(), []string{"corp.example.com"}); err != nil {
+		t.Fatalf("failed to set DNSSEC negative trust anchors: %v", err)
+	}
+}
+
+func TestLinkServiceRevert(t *testing.T) {
+	var methods []string
+	c := &Client{
+		call: func(_ context.Context, _, method string, _ dbus.ObjectPath, _ any, _ ...any) error {
+			methods = append(methods, method)
+			return nil
+		},
+	}
+
+	if err := c.Link(testLink).Revert(context.Background()); err != nil {
+		t.Fatalf("failed to revert: %v", err)
+	}
+
+	want := []string{interfacePath("Link", "RevertNTP"), interfacePath("Link", "RevertDNS")}
+	if diff := cmp.Diff(want, methods); diff != "" {
+		t.Fatalf("unexpected methods (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkServiceRevertNTP(t *testing.T) {
+	ls := testLinkService(t, "RevertNTP", nil, nil)
+
+	if err := ls.RevertNTP(context.Background()); err != nil {
+		t.Fatalf("failed to revert NTP: %v", err)
+	}
+}
+
+func TestLinkServiceRevertDNS(t *testing.T) {
+	ls := testLinkService(t, "RevertDNS", nil, nil)
+
+	if err := ls.RevertDNS(context.Background()); err != nil {
+		t.Fatalf("failed to revert DNS: %v", err)
+	}
+}
+
+func TestLinkServiceRenew(t *testing.T) {
+	ls := testLinkService(t, "Renew", nil, nil)
+
+	if err := ls.Renew(context.Background()); err != nil {
+		t.Fatalf("failed to renew: %v", err)
+	}
+}
+
+func TestLinkServiceReconfigure(t *testing.T) {
+	ls := testLinkService(t, "Reconfigure", nil, nil)
+
+	if err := ls.Reconfigure(context.Background(), nil); err != nil {
+		t.Fatalf("failed to reconfigure: %v", err)
+	}
+}
+
+func TestLinkServiceReconfigureKeepConfiguration(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		methods []string
+	)
+
+	c := &Client{
+		call: func(_ context.Context, _, method string, _ dbus.ObjectPath, out any, _ ...any) error {
+			methods = append(methods, method)
+
+			switch method {
+			case interfacePath("Link", "Describe"):
+				const desc = `{"Index":2,"Name":"eth0","NetworkFile":"/etc/systemd/network/10-eth0.network"}`
+				return dbus.Store([]any{desc}, out)
+			case interfacePath("Manager", "Reload"), interfacePath("Link", "Reconfigure"):
+				return nil
+			default:
+				t.Fatalf("unexpected method: %q", method)
+				return nil
+			}
+		},
+	}
+	c.Manager = &ManagerService{c: c}
+
+	opts := &ReconfigureOptions{KeepConfiguration: "static", Dir: dir}
+	if err := c.Link(testLink).Reconfigure(context.Background(), opts); err != nil {
+		t.Fatalf("failed to reconfigure: %v", err)
+	}
+
+	// The drop-in must be in place before networkd is reloaded and the link
+	// is reconfigured.
+	want := []string{
+		interfacePath("Link", "Describe"),
+		interfacePath("Manager", "Reload"),
+		interfacePath("Link", "Reconfigure"),
+	}
+	if diff := cmp.Diff(want, methods); diff != "" {
+		t.Fatalf("unexpected methods (-want +got):\n%s", diff)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "10-eth0.network.d", "keep-configuration.conf"))
+	if err != nil {
+		t.Fatalf("failed to read drop-in: %v", err)
+	}
+
+	if diff := cmp.Diff("[Network]\nKeepConfiguration=static\n", string(b)); diff != "" {
+		t.Fatalf("unexpected drop-in (-want +got):\n%s", diff)
+	}
+
+	// Invalid values are rejected before anything is written.
+	methods = nil
+	opts.KeepConfiguration = "sometimes"
+	if err := c.Link(testLink).Reconfigure(context.Background(), opts); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+	if len(methods) != 0 {
+		t.Fatalf("unexpected methods: %v", methods)
+	}
+}
+
+func TestLinkServiceDescribeRaw(t *testing.T) {
+	const want = `{"Index":2,"Name":"eth0"}`
+	ls := testLinkService(t, "Describe", nil, want)
+
+	b, err := ls.DescribeRaw(context.Background())
+	if err != nil {
+		t.Fatalf("failed to describe: %v", err)
+	}
+
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Fatalf("unexpected description (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkServiceProperties(t *testing.T) {
+	c := &Client{
+		getAll: func(_ context.Context, op dbus.ObjectPath, iface string) (map[string]dbus.Variant, error) {
+			if diff := cmp.Diff(testLink.ObjectPath, op); diff != "" {
+				t.Fatalf("unexpected object path (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(interfacePath("Link"), iface); diff != "" {
+				t.Fatalf("unexpected interface (-want +got):\n%s", diff)
+			}
+
+			return testLinkProperties(), nil
+		},
+	}
+
+	props, err := c.Link(testLink).Properties(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get properties: %v", err)
+	}
+
+	want := LinkProperties{
+		OperationalState:    OperationalRoutable,
+		CarrierState:        CarrierCarrier,
+		AddressState:        AddressRoutable,
+		IPv4AddressState:    AddressRoutable,
+		IPv6AddressState:    AddressDegraded,
+		OnlineState:         OnlineOnline,
+		AdministrativeState: AdministrativeConfigured,
+		TxBitRate:           1000000,
+		RxBitRate:           2000000,
+		Raw:                 testLinkProperties(),
+	}
+
+	if diff := cmp.Diff(want, props, cmp.Comparer(variantEqual)); diff != "" {
+		t.Fatalf("unexpected properties (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkServiceIsManaged(t *testing.T) {
+	tests := []struct {
+		state   AdministrativeState
+		managed bool
+	}{
+		{state: AdministrativePending},
+		{state: AdministrativeInitialized},
+		{state: AdministrativeConfiguring, managed: true},
+		{state: AdministrativeConfigured, managed: true},
+		{state: AdministrativeFailed, managed: true},
+		{state: AdministrativeUnmanaged},
+		{state: AdministrativeLinger},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			c := &Client{
+				get: func(_ context.Context, _ dbus.ObjectPath, _, prop string) (dbus.Variant, error) {
+					if diff := cmp.Diff("AdministrativeState", prop); diff != "" {
+						t.Fatalf("unexpected property (-want +got):\n%s", diff)
+					}
+
+					return dbus.MakeVariant(string(tt.state)), nil
+				},
+			}
+
+			managed, err := c.Link(testLink).IsManaged(context.Background())
+			if err != nil {
+				t.Fatalf("failed to check managed: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.managed, managed); diff != "" {
+				t.Fatalf("unexpected managed state (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLinkServiceIsManagedBadType(t *testing.T) {
+	c := &Client{
+		get: func(_ context.Context, _ dbus.ObjectPath, _, _ string) (dbus.Variant, error) {
+			return dbus.MakeVariant(uint32(1)), nil
+		},
+	}
+
+	if _, err := c.Link(testLink).IsManaged(context.Background()); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestLinkPropertiesReady(t *testing.T) {
+	routable := LinkProperties{
+		OperationalState:    OperationalRoutable,
+		CarrierState:        CarrierCarrier,
+		AddressState:        AddressRoutable,
+		AdministrativeState: AdministrativeConfigured,
+	}
+
+	tests := []struct {
+		name  string
+		lp    LinkProperties
+		min   OperationalState
+		ready bool
+	}{
+		{
+			name:  "routable",
+			lp:    routable,
+			min:   OperationalRoutable,
+			ready: true,
+		},
+		{
+			name:  "routable exceeds degraded",
+			lp:    routable,
+			min:   OperationalDegraded,
+			ready: true,
+		},
+		{
+			name: "configuring",
+			lp: func() LinkProperties {
+				lp := routable
+				lp.AdministrativeState = AdministrativeConfiguring
+				return lp
+			}(),
+			min: OperationalDegraded,
+		},
+		{
+			name: "degraded",
+			lp: LinkProperties{
+				OperationalState:    OperationalDegraded,
+				CarrierState:        CarrierCarrier,
+				AddressState:        AddressDegraded,
+				AdministrativeState: AdministrativeConfigured,
+			},
+			min: OperationalRoutable,
+		},
+		{
+			name: "no address",
+			lp: LinkProperties{
+				OperationalState:    OperationalCarrier,
+				CarrierState:        CarrierCarrier,
+				AddressState:        AddressOff,
+				AdministrativeState: AdministrativeUnmanaged,
+			},
+			min:   OperationalCarrier,
+			ready: true,
+		},
+		{
+			name: "unknown state",
+			lp: func() LinkProperties {
+				lp := routable
+				lp.OperationalState = "bogus"
+				return lp
+			}(),
+			min: OperationalOff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.ready, tt.lp.Ready(tt.min)); diff != "" {
+				t.Fatalf("unexpected readiness (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLinkServiceWaitForState(t *testing.T) {
+	signals := make(chan *dbus.Signal, 3)
+	var canceled bool
+	c := &Client{
+		get: func(_ context.Context, _ dbus.ObjectPath, _, prop string) (dbus.Variant, error) {
+			if diff := cmp.Diff("OperationalState", prop); diff != "" {
+				t.Fatalf("unexpected property (-want +got):\n%s", diff)
+			}
+
+			return dbus.MakeVariant("no-carrier"), nil
+		},
+		watch: func(_ context.Context, _ ...dbus.MatchOption) (<-chan *dbus.Signal, func() error, error) {
+			return signals, func() error {
+				canceled = true
+				return nil
+			}, nil
+		},
+	}
+
+	// Signals for other objects and states which do not satisfy the minimum
+	// are ignored.
+	signals <- testPropertiesChanged(objectPath("link", "_33"), "Link", "OperationalState", "routable")
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "carrier")
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "routable")
+
+	if err := c.Link(testLink).WaitForState(context.Background(), OperationalRoutable); err != nil {
+		t.Fatalf("failed to wait for state: %v", err)
+	}
+	if len(signals) != 0 {
+		t.Fatalf("expected all signals to be consumed, but %d remain", len(signals))
+	}
+	if !canceled {
+		t.Fatal("watch was not canceled")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.Link(testLink).WaitForState(ctx, OperationalRoutable); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, but got: %v", err)
+	}
+}
+
+func TestLinkServiceWaitForStateBadType(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial any
+		signal  any
+	}{
+		{name: "initial", initial: uint32(1)},
+		{name: "signal", initial: "no-carrier", signal: uint32(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signals := make(chan *dbus.Signal, 1)
+			c := &Client{
+				get: func(_ context.Context, _ dbus.ObjectPath, _, _ string) (dbus.Variant, error) {
+					return dbus.MakeVariant(tt.initial), nil
+				},
+				watch: func(_ context.Context, _ ...dbus.MatchOption) (<-chan *dbus.Signal, func() error, error) {
+					return signals, func() error { return nil }, nil
+				},
+			}
+
+			if tt.signal != nil {
+				signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", tt.signal)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := c.Link(testLink).WaitForState(ctx, OperationalRoutable)
+			if err == nil || errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected a type error, but got: %v", err)
+			}
+		})
+	}
+}
+
+// testPropertiesChanged produces a PropertiesChanged signal for a single
+// property on a networkd interface.
+func testPropertiesChanged(op dbus.ObjectPath, iface, prop string, value any) *dbus.Signal {
+	return &dbus.Signal{
+		Path: op,
+		Name: signalPropertiesChanged,
+		Body: []any{
+			interfacePath(iface),
+			map[string]dbus.Variant{prop: dbus.MakeVariant(value)},
+			[]string{},
+		},
+	}
+}
+
+// variantEqual compares D-Bus variants for equality.
+func variantEqual(x, y dbus.Variant) bool {
+	return x.Signature() == y.Signature() && reflect.DeepEqual(x.Value(), y.Value())
+}
+
+// testLinkProperties returns the D-Bus properties for a typical routable link.
+func testLinkProperties() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"OperationalState":    dbus.MakeVariant("routable"),
+		"CarrierState":        dbus.MakeVariant("carrier"),
+		"AddressState":        dbus.MakeVariant("routable"),
+		"IPv4AddressState":    dbus.MakeVariant("routable"),
+		"IPv6AddressState":    dbus.MakeVariant("degraded"),
+		"OnlineState":         dbus.MakeVariant("online"),
+		"AdministrativeState": dbus.MakeVariant("configured"),
+		"BitRates":            dbus.MakeVariant([]any{uint64(1000000), uint64(2000000)}),
+	}
+}
+
+// testLinkService produces a LinkService for testLink which verifies that the
+// D-Bus method is called with the expected arguments. If out is not nil, it is
+// stored in the caller's output pointer.
+func testLinkService(t *testing.T, method string, want []any, out any) *LinkService {
+	t.Helper()
+
+	c := &Client{
+		call: func(_ context.Context, service, m string, op dbus.ObjectPath, pout any, args ...any) error {
+			if diff := cmp.Diff(baseService, service); diff != "" {
+				t.Fatalf("unexpected service (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(interfacePath("Link", method), m); diff != "" {
+				t.Fatalf("unexpected method (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(testLink.ObjectPath, op); diff != "" {
+				t.Fatalf("unexpected object path (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(want, args); diff != "" {
+				t.Fatalf("unexpected arguments (-want +got):\n%s", diff)
+			}
+
+			if out != nil {
+				return dbus.Store([]any{out}, pout)
+			}
+
+			return nil
+		},
+	}
+
+	return c.Link(testLink)
+}
+
+func TestParseLinkProperties(t *testing.T) {
+	tests := []struct {
+		name  string
+		out   map[string]dbus.Variant
+		props LinkProperties
+		ok    bool
+	}{
+		{
+			name: "bad state",
+			out:  map[string]dbus.Variant{"OperationalState": dbus.MakeVariant(uint32(1))},
+		},
+		{
+			name: "bad bit rates",
+			out:  map[string]dbus.Variant{"BitRates": dbus.MakeVariant([]any{"fast", "slow"})},
+		},
+		{
+			name: "short bit rates",
+			out:  map[string]dbus.Variant{"BitRates": dbus.MakeVariant([]any{uint64(1)})},
+		},
+		{
+			name: "missing",
+			out: map[string]dbus.Variant{
+				"OperationalState": dbus.MakeVariant("routable"),
+			},
+			props: LinkProperties{OperationalState: OperationalRoutable},
+			ok:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props, err := parseLinkProperties(tt.out)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse properties: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if diff := cmp.Diff(tt.props, props, cmpopts.IgnoreFields(LinkProperties{}, "Raw")); diff != "" {
+				t.Fatalf("unexpected properties (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package networkd
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+)
+
+// An LLDPNeighbor is a Link Layer Discovery Protocol neighbor discovered by
+// networkd on a link.
+type LLDPNeighbor struct {
+	// ChassisID and PortID are the human-readable chassis and port IDs of
+	// the neighbor. RawChassisID and RawPortID contain the raw ID subtype
+	// and value as sent by the neighbor.
+	ChassisID, PortID       string
+	RawChassisID, RawPortID []byte
+
+	// Optional descriptive information about the neighbor.
+	PortDescription   string
+	SystemName        string
+	SystemDescription string
+
+	// EnabledCapabilities is the bitmask of system capabilities enabled on
+	// the neighbor.
+	EnabledCapabilities LLDPCapabilities
+
+	// VLANID is the port VLAN ID of the neighbor, or 0 if not set.
+	VLANID uint16
+
+	// The remaining fields are not included in networkd's descriptions of
+	// links, so they are only set when neighbors are parsed from raw LLDP
+	// frames by ParseLLDPNeighbors and ReadLLDPNeighbors.
+
+	// ManagementAddresses are the IP addresses at which the neighbor may be
+	// managed.
+	ManagementAddresses []netip.Addr
+
+	// VLANNames are the names of the VLANs configured on the neighbor's port.
+	VLANNames []LLDPVLANName
+
+	// MAUType is the IEEE 802.3 medium attachment unit type of the neighbor's
+	// port, such as 30 for 1000BASE-T full duplex, or 0 if not set.
+	MAUType uint16
+
+	// MED is the LLDP Media Endpoint Discovery information sent by the
+	// neighbor, or nil if not set.
+	MED *LLDPMED
+
+	// TLVs are all of the raw TLVs sent by the neighbor, excluding the end
+	// TLV.
+	TLVs []LLDPTLV
+}
+
+// An LLDPTLV is a raw LLDP type-length-value structure.
+type LLDPTLV struct {
+	Type  uint8
+	Value []byte
+}
+
+// An LLDPVLANName is the name of a VLAN sent by an LLDP neighbor.
+type LLDPVLANName struct {
+	ID   uint16
+	Name string
+}
+
+// An LLDPMED contains the LLDP Media Endpoint Discovery (LLDP-MED) information
+// sent by a neighbor, as defined by ANSI/TIA-1057.
+type LLDPMED struct {
+	// Capabilities is the bitmask of LLDP-MED capabilities supported by the
+	// neighbor, and DeviceType is its device class.
+	Capabilities uint16
+	DeviceType   uint8
+
+	// Inventory information about the neighbor.
+	HardwareRevision string
+	FirmwareRevision string
+	SoftwareRevision string
+	SerialNumber     string
+	Manufacturer     string
+	Model            string
+	AssetID          string
+}
+
+// ChassisIDSubtype returns the subtype of the neighbor's chassis ID, or 0 if
+// the neighbor did not send a chassis ID.
+func (n LLDPNeighbor) ChassisIDSubtype() LLDPChassisIDSubtype {
+	if len(n.RawChassisID) == 0 {
+		return 0
+	}
+
+	return LLDPChassisIDSubtype(n.RawChassisID[0])
+}
+
+// PortIDSubtype returns the subtype of the neighbor's port ID, or 0 if the
+// neighbor did not send a port ID.
+func (n LLDPNeighbor) PortIDSubtype() LLDPPortIDSubtype {
+	if len(n.RawPortID) == 0 {
+		return 0
+	}
+
+	return LLDPPortIDSubtype(n.RawPortID[0])
+}
+
+// LLDPCapabilities is a bitmask of LLDP system capabilities.
+type LLDPCapabilities uint16
+
+// Possible LLDPCapabilities bits.
+const (
+	LLDPCapabilityOther LLDPCapabilities = 1 << iota
+	LLDPCapabilityRepeater
+	LLDPCapabilityBridge
+	LLDPCapabilityWLANAccessPoint
+	LLDPCapabilityRouter
+	LLDPCapabilityTelephone
+	LLDPCapabilityDOCSIS
+	LLDPCapabilityStation
+	LLDPCapabilityCustomerVLAN
+	LLDPCapabilityServiceVLAN
+	LLDPCapabilityTwoPortMACRelay
+)
+
+// String returns the string representation of LLDPCapabilities in the format
+// used by the CAPS column of networkctl lldp, such as "..b.r......" for a
+// bridge and router.
+func (c LLDPCapabilities) String() string {
+	const chars = "opbwrtdacsm"
+
+	b := make([]byte, len(chars))
+	for i := range chars {
+		if c&(1<<i) != 0 {
+			b[i] = chars[i]
+		} else {
+			b[i] = '.'
+		}
+	}
+
+	return string(b)
+}
+
+// An LLDPChassisIDSubtype indicates the format of an LLDP chassis ID.
+type LLDPChassisIDSubtype uint8
+
+// Possible LLDPChassisIDSubtype values.
+const (
+	_ LLDPChassisIDSubtype = iota
+	LLDPChassisIDChassisComponent
+	LLDPChassisIDInterfaceAlias
+	LLDPChassisIDPortComponent
+	LLDPChassisIDMACAddress
+	LLDPChassisIDNetworkAddress
+	LLDPChassisIDInterfaceName
+	LLDPChassisIDLocallyAssigned
+)
+
+// String returns the string representation of an LLDPChassisIDSubtype.
+func (s LLDPChassisIDSubtype) String() string {
+	switch s {
+	case LLDPChassisIDChassisComponent:
+		return "chassis component"
+	case LLDPChassisIDInterfaceAlias:
+		return "interface alias"
+	case LLDPChassisIDPortComponent:
+		return "port component"
+	case LLDPChassisIDMACAddress:
+		return "MAC address"
+	case LLDPChassisIDNetworkAddress:
+		return "network address"
+	case LLDPChassisIDInterfaceName:
+		return "interface name"
+	case LLDPChassisIDLocallyAssigned:
+		return "locally assigned"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// An LLDPPortIDSubtype indicates the format of an LLDP port ID.
+type LLDPPortIDSubtype uint8
+
+// Possible LLDPPortIDSubtype values.
+const (
+	_ LLDPPortIDSubtype = iota
+	LLDPPortIDInterfaceAlias
+	LLDPPortIDPortComponent
+	LLDPPortIDMACAddress
+	LLDPPortIDNetworkAddress
+	LLDPPortIDInterfaceName
+	LLDPPortIDAgentCircuitID
+	LLDPPortIDLocallyAssigned
+)
+
+// String returns the string representation of an LLDPPortIDSubtype.
+func (s LLDPPortIDSubtype) String() string {
+	switch s {
+	case LLDPPortIDInterfaceAlias:
+		return "interface alias"
+	case LLDPPortIDPortComponent:
+		return "port component"
+	case LLDPPortIDMACAddress:
+		return "MAC address"
+	case LLDPPortIDNetworkAddress:
+		return "network address"
+	case LLDPPortIDInterfaceName:
+		return "interface name"
+	case LLDPPortIDAgentCircuitID:
+		return "agent circuit ID"
+	case LLDPPortIDLocallyAssigned:
+		return "locally assigned"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// LLDPNeighbors returns the LLDP neighbors which networkd has discovered on
+// this link. LLDP reception must be enabled in the link's configuration for
+// networkd to discover neighbors.
+func (ls *LinkService) LLDPNeighbors(ctx context.Context) ([]LLDPNeighbor, error) {
+	d, err := ls.Describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.LLDPNeighbors, nil
+}
+
+// toLLDPNeighbors converts JSON LLDP neighbors to LLDPNeighbors.
+func toLLDPNeighbors(jns []jsonLLDPNeighbor) []LLDPNeighbor {
+	if len(jns) == 0 {
+		return nil
+	}
+
+	ns := make([]LLDPNeighbor, 0, len(jns))
+	for _, jn := range jns {
+		ns = append(ns, LLDPNeighbor{
+			ChassisID:           jn.ChassisID,
+			PortID:              jn.PortID,
+			RawChassisID:        jn.RawChassisID,
+			RawPortID:           jn.RawPortID,
+			PortDescription:     jn.PortDescription,
+			SystemName:          jn.SystemName,
+			SystemDescription:   jn.SystemDescription,
+			EnabledCapabilities: LLDPCapabilities(jn.EnabledCapabilities),
+			VLANID:              jn.VLANID,
+		})
+	}
+
+	return ns
+}
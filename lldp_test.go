@@ -0,0 +1,64 @@
+package networkd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLLDPCapabilitiesString(t *testing.T) {
+	tests := []struct {
+		name string
+		c    LLDPCapabilities
+		s    string
+	}{
+		{
+			name: "none",
+			s:    "...........",
+		},
+		{
+			name: "bridge and router",
+			c:    20,
+			s:    "..b.r......",
+		},
+		{
+			name: "other, telephone, and TPMR",
+			c:    LLDPCapabilityOther | LLDPCapabilityTelephone | LLDPCapabilityTwoPortMACRelay,
+			s:    "o....t....m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.s, tt.c.String()); diff != "" {
+				t.Fatalf("unexpected string (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLLDPNeighborSubtypes(t *testing.T) {
+	type subtypes struct {
+		Chassis, Port string
+	}
+
+	got := []subtypes{
+		{
+			Chassis: testLLDPNeighbor.ChassisIDSubtype().String(),
+			Port:    testLLDPNeighbor.PortIDSubtype().String(),
+		},
+		{
+			Chassis: LLDPNeighbor{}.ChassisIDSubtype().String(),
+			Port:    LLDPNeighbor{RawPortID: []byte{6, 'x'}}.PortIDSubtype().String(),
+		},
+	}
+
+	want := []subtypes{
+		{Chassis: "MAC address", Port: "interface name"},
+		{Chassis: "unknown(0)", Port: "agent circuit ID"},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected subtypes (-want +got):\n%s", diff)
+	}
+}
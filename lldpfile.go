@@ -0,0 +1,440 @@
+package networkd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lldpDir is the directory in which networkd stores the LLDP neighbors it
+// discovers.
+const lldpDir = "/run/systemd/netif/lldp"
+
+// ReadLLDPNeighbors reads the LLDP neighbors which networkd stores in dir, as a
+// fallback for systems on which LLDP neighbors are not available over D-Bus. If
+// dir is empty, networkd's default of /run/systemd/netif/lldp is used. The
+// returned map is keyed by interface index. Files which are removed while dir
+// is being read are skipped.
+func ReadLLDPNeighbors(dir string) (map[int][]LLDPNeighbor, error) {
+	if dir == "" {
+		dir = lldpDir
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read LLDP neighbors: %w", err)
+	}
+
+	neighbors := make(map[int][]LLDPNeighbor, len(entries))
+	for _, e := range entries {
+		// Each neighbor file is named after its interface index.
+		index, err := strconv.Atoi(e.Name())
+		if err != nil || !e.Type().IsRegular() {
+			continue
+		}
+
+		ns, err := readLLDPNeighbors(filepath.Join(dir, e.Name()))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			// The link disappeared after the directory was read.
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		neighbors[index] = ns
+	}
+
+	return neighbors, nil
+}
+
+// readLLDPNeighbors reads a single LLDP neighbor file.
+func readLLDPNeighbors(file string) ([]LLDPNeighbor, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("read LLDP neighbors: %w", err)
+	}
+	defer f.Close()
+
+	ns, err := ParseLLDPNeighbors(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse LLDP neighbors %q: %w", file, err)
+	}
+
+	return ns, nil
+}
+
+// ParseLLDPNeighbors parses the binary format which networkd uses to store LLDP
+// neighbors in /run/systemd/netif/lldp: each neighbor's raw Ethernet frame,
+// prefixed by its length as a 64-bit little-endian integer.
+func ParseLLDPNeighbors(r io.Reader) ([]LLDPNeighbor, error) {
+	var ns []LLDPNeighbor
+	for {
+		var size uint64
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			if errors.Is(err, io.EOF) {
+				return ns, nil
+			}
+
+			return nil, err
+		}
+
+		// An LLDP frame must fit in a single Ethernet frame.
+		if size > 1<<16 {
+			return nil, fmt.Errorf("invalid LLDP frame size: %d", size)
+		}
+
+		b := make([]byte, size)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+
+		n, err := parseLLDPFrame(b)
+		if err != nil {
+			return nil, err
+		}
+
+		ns = append(ns, n)
+	}
+}
+
+// LLDP TLV types.
+const (
+	lldpEnd                  = 0
+	lldpChassisID            = 1
+	lldpPortID               = 2
+	lldpPortDescription      = 4
+	lldpSystemName           = 5
+	lldpSystemDescription    = 6
+	lldpSystemCapabilities   = 7
+	lldpManagementAddress    = 8
+	lldpOrganizationSpecific = 127
+)
+
+// Organizationally unique identifiers for organization-specific TLVs.
+var (
+	ouiIEEE8021 = [3]byte{0x00, 0x80, 0xc2}
+	ouiIEEE8023 = [3]byte{0x00, 0x12, 0x0f}
+	ouiTIA      = [3]byte{0x00, 0x12, 0xbb}
+)
+
+// parseLLDPFrame parses an LLDPNeighbor from a raw Ethernet frame.
+func parseLLDPFrame(b []byte) (LLDPNeighbor, error) {
+	const etherTypeLLDP = 0x88cc
+
+	if len(b) < 14 || binary.BigEndian.Uint16(b[12:14]) != etherTypeLLDP {
+		return LLDPNeighbor{}, errors.New("invalid LLDP Ethernet frame")
+	}
+
+	tlvs, err := parseLLDPTLVs(b[14:])
+	if err != nil {
+		return LLDPNeighbor{}, err
+	}
+
+	n := LLDPNeighbor{TLVs: tlvs}
+	for _, t := range tlvs {
+		switch t.Type {
+		case lldpChassisID:
+			n.RawChassisID = t.Value
+			n.ChassisID = lldpID(t.Value, uint8(LLDPChassisIDMACAddress), uint8(LLDPChassisIDNetworkAddress))
+		case lldpPortID:
+			n.RawPortID = t.Value
+			n.PortID = lldpID(t.Value, uint8(LLDPPortIDMACAddress), uint8(LLDPPortIDNetworkAddress))
+		case lldpPortDescription:
+			n.PortDescription = string(t.Value)
+		case lldpSystemName:
+			n.SystemName = string(t.Value)
+		case lldpSystemDescription:
+			n.SystemDescription = string(t.Value)
+		case lldpSystemCapabilities:
+			// The system capabilities are followed by the enabled
+			// capabilities.
+			if len(t.Value) == 4 {
+				n.EnabledCapabilities = LLDPCapabilities(binary.BigEndian.Uint16(t.Value[2:4]))
+			}
+		case lldpManagementAddress:
+			// The address string length includes its IANA address family.
+			v := t.Value
+			if len(v) < 2 || v[0] < 2 || len(v) < 1+int(v[0]) {
+				continue
+			}
+			if ip, ok := netip.AddrFromSlice(v[2 : 1+v[0]]); ok {
+				n.ManagementAddresses = append(n.ManagementAddresses, ip)
+			}
+		case lldpOrganizationSpecific:
+			if len(t.Value) < 4 {
+				continue
+			}
+
+			n.organizationSpecific([3]byte(t.Value[:3]), t.Value[3], t.Value[4:])
+		}
+	}
+
+	return n, nil
+}
+
+// organizationSpecific decodes the value of an organization-specific TLV with
+// the input OUI and subtype into n. Unknown TLVs are ignored.
+func (n *LLDPNeighbor) organizationSpecific(oui [3]byte, subtype uint8, v []byte) {
+	switch oui {
+	case ouiIEEE8021:
+		switch {
+		case subtype == 1 && len(v) == 2:
+			// Port VLAN ID.
+			n.VLANID = binary.BigEndian.Uint16(v)
+		case subtype == 3 && len(v) >= 3 && len(v) == 3+int(v[2]):
+			// VLAN ID and name.
+			n.VLANNames = append(n.VLANNames, LLDPVLANName{
+				ID:   binary.BigEndian.Uint16(v[:2]),
+				Name: string(v[3:]),
+			})
+		}
+	case ouiIEEE8023:
+		// MAC/PHY configuration: autonegotiation support and advertised
+		// capabilities, followed by the MAU type.
+		if subtype == 1 && len(v) == 5 {
+			n.MAUType = binary.BigEndian.Uint16(v[3:5])
+		}
+	case ouiTIA:
+		if n.MED == nil {
+			n.MED = &LLDPMED{}
+		}
+
+		switch s := string(v); subtype {
+		case 1:
+			if len(v) == 3 {
+				n.MED.Capabilities = binary.BigEndian.Uint16(v[:2])
+				n.MED.DeviceType = v[2]
+			}
+		case 5:
+			n.MED.HardwareRevision = s
+		case 6:
+			n.MED.FirmwareRevision = s
+		case 7:
+			n.MED.SoftwareRevision = s
+		case 8:
+			n.MED.SerialNumber = s
+		case 9:
+			n.MED.Manufacturer = s
+		case 10:
+			n.MED.Model = s
+		case 11:
+			n.MED.AssetID = s
+		}
+	}
+}
+
+// parseLLDPTLVs parses the TLVs of an LLDP frame up to the end TLV.
+func parseLLDPTLVs(b []byte) ([]LLDPTLV, error) {
+	var tlvs []LLDPTLV
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, errors.New("truncated LLDP TLV header")
+		}
+
+		// The type is the upper 7 bits of the header, and the length the
+		// lower 9 bits.
+		h := binary.BigEndian.Uint16(b[:2])
+		typ, n := uint8(h>>9), int(h&0x1ff)
+		if typ == lldpEnd {
+			break
+		}
+		if len(b) < 2+n {
+			return nil, fmt.Errorf("LLDP TLV %d length %d exceeds remaining data", typ, n)
+		}
+
+		tlvs = append(tlvs, LLDPTLV{Type: typ, Value: b[2 : 2+n : 2+n]})
+		b = b[2+n:]
+	}
+
+	return tlvs, nil
+}
+
+// lldpID formats a raw chassis or port ID as networkd does, given the subtypes
+// which indicate a MAC address or network address. Other printable IDs are
+// used as-is, and the rest are hex encoded.
+func lldpID(raw []byte, mac, network uint8) string {
+	if len(raw) < 2 {
+		return hex.EncodeToString(raw)
+	}
+
+	switch id := raw[1:]; raw[0] {
+	case mac:
+		if len(id) == 6 {
+			return net.HardwareAddr(id).String()
+		}
+	case network:
+		// The address is preceded by its IANA address family.
+		if ip, ok := netip.AddrFromSlice(id[1:]); ok && (id[0] == 1 && ip.Is4() || id[0] == 2 && ip.Is6()) {
+			return ip.String()
+		}
+	default:
+		if printable(id) {
+			return string(id)
+		}
+	}
+
+	return hex.EncodeToString(raw)
+}
+
+// printable reports whether b is a printable UTF-8 string.
+func printable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// An LLDPNeighborEvent indicates that an LLDP neighbor appeared or changed on
+// the link with the given index, or that it aged out when Removed is set.
+type LLDPNeighborEvent struct {
+	Index    int
+	Neighbor LLDPNeighbor
+	Removed  bool
+}
+
+// WatchLLDPNeighbors watches the LLDP neighbors which networkd stores in dir,
+// as with ReadLLDPNeighbors, by reading them again at the input interval.
+// Events for the neighbors present when the watch begins are sent first,
+// followed by events as neighbors appear, change, or age out. Events are sent
+// on the returned channel until ctx is canceled, at which point the channel is
+// closed.
+//
+// networkd does not report changes to LLDP neighbors over D-Bus, so the
+// neighbors are polled. A missing directory is treated as having no neighbors,
+// and a file which is truncated while it is read is read again on the next
+// poll. If the neighbors cannot be read for any other reason, the channel is
+// closed.
+func WatchLLDPNeighbors(ctx context.Context, dir string, interval time.Duration) (<-chan LLDPNeighborEvent, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid LLDP neighbor polling interval: %v", interval)
+	}
+
+	read := func() (map[int][]LLDPNeighbor, error) {
+		// Files which vanish during the read are skipped, so a not exist
+		// error means that the directory itself is missing.
+		ns, err := ReadLLDPNeighbors(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return ns, err
+	}
+
+	neighbors, err := read()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan LLDPNeighborEvent)
+	go func() {
+		defer close(out)
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		var prev map[int][]LLDPNeighbor
+		for {
+			for _, e := range diffLLDPNeighbors(prev, neighbors) {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- e:
+				}
+			}
+			prev = neighbors
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			next, err := read()
+			switch {
+			case errors.Is(err, os.ErrNotExist), errors.Is(err, io.ErrUnexpectedEOF):
+				// Files may be replaced while they are read, so try again on
+				// the next tick.
+				neighbors = prev
+				continue
+			case err != nil:
+				return
+			}
+			neighbors = next
+		}
+	}()
+
+	return out, nil
+}
+
+// diffLLDPNeighbors produces LLDPNeighborEvents for the neighbors removed from
+// prev and the neighbors added or changed in next, ordered by link index.
+// Neighbors are identified by their link, chassis ID, and port ID.
+func diffLLDPNeighbors(prev, next map[int][]LLDPNeighbor) []LLDPNeighborEvent {
+	find := func(ns []LLDPNeighbor, n LLDPNeighbor) (LLDPNeighbor, bool) {
+		for _, nn := range ns {
+			if bytes.Equal(nn.RawChassisID, n.RawChassisID) && bytes.Equal(nn.RawPortID, n.RawPortID) {
+				return nn, true
+			}
+		}
+
+		return LLDPNeighbor{}, false
+	}
+
+	var events []LLDPNeighborEvent
+	for index, ns := range prev {
+		for _, n := range ns {
+			if _, ok := find(next[index], n); !ok {
+				events = append(events, LLDPNeighborEvent{Index: index, Neighbor: n, Removed: true})
+			}
+		}
+	}
+	for index, ns := range next {
+		for _, n := range ns {
+			if p, ok := find(prev[index], n); ok && slices.EqualFunc(p.TLVs, n.TLVs, func(a, b LLDPTLV) bool {
+				return a.Type == b.Type && bytes.Equal(a.Value, b.Value)
+			}) {
+				continue
+			}
+
+			events = append(events, LLDPNeighborEvent{Index: index, Neighbor: n})
+		}
+	}
+
+	// Removals precede additions for each link.
+	slices.SortStableFunc(events, func(a, b LLDPNeighborEvent) int {
+		if a.Index != b.Index {
+			return a.Index - b.Index
+		}
+		if a.Removed != b.Removed {
+			if a.Removed {
+				return -1
+			}
+			return 1
+		}
+
+		return 0
+	})
+
+	return events
+}
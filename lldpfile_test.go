@@ -0,0 +1,336 @@
+package networkd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseLLDPNeighbors(t *testing.T) {
+	// Replace the end TLV with a truncated TLV.
+	truncated := testLLDPFrame()
+	truncated = append(truncated[:len(truncated)-2], 0x02, 0x10)
+
+	tests := []struct {
+		name string
+		b    []byte
+		ns   []LLDPNeighbor
+		ok   bool
+	}{
+		{
+			name: "empty",
+			ok:   true,
+		},
+		{
+			name: "truncated size",
+			b:    []byte{1, 0},
+		},
+		{
+			name: "truncated frame",
+			b:    testLLDPFile(testLLDPFrame())[:20],
+		},
+		{
+			name: "not LLDP",
+			b:    testLLDPFile(make([]byte, 14)),
+		},
+		{
+			name: "truncated TLV",
+			b:    testLLDPFile(truncated),
+		},
+		{
+			name: "OK",
+			b:    testLLDPFile(testLLDPFrame(), testLLDPFrame()),
+			ns:   []LLDPNeighbor{testLLDPNeighbor, testLLDPNeighbor},
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns, err := ParseLLDPNeighbors(bytes.NewReader(tt.b))
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse neighbors: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			// Raw TLVs are tested separately.
+			if diff := cmp.Diff(tt.ns, ns, cmpopts.IgnoreFields(LLDPNeighbor{}, "TLVs")); diff != "" {
+				t.Fatalf("unexpected neighbors (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadLLDPNeighbors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "2"), testLLDPFile(testLLDPFrame()), 0o644); err != nil {
+		t.Fatalf("failed to write neighbors: %v", err)
+	}
+
+	ns, err := ReadLLDPNeighbors(dir)
+	if err != nil {
+		t.Fatalf("failed to read neighbors: %v", err)
+	}
+
+	want := map[int][]LLDPNeighbor{2: {testLLDPNeighbor}}
+	if diff := cmp.Diff(want, ns, cmpopts.IgnoreFields(LLDPNeighbor{}, "TLVs")); diff != "" {
+		t.Fatalf("unexpected neighbors (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadLLDPNeighbors(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, but got: %v", err)
+	}
+}
+
+func TestParseLLDPFrameTLVs(t *testing.T) {
+	frame := testLLDPFrame(
+		// Management addresses, including one which is malformed.
+		LLDPTLV{Type: 8, Value: []byte{5, 1, 192, 0, 2, 10, 2, 0, 0, 0, 1, 0}},
+		LLDPTLV{Type: 8, Value: []byte{0, 1}},
+		// IEEE 802.1 VLAN name.
+		LLDPTLV{Type: 127, Value: []byte{0x00, 0x80, 0xc2, 0x03, 0x00, 0x0a, 0x04, 'd', 'a', 't', 'a'}},
+		// IEEE 802.3 MAC/PHY configuration.
+		LLDPTLV{Type: 127, Value: []byte{0x00, 0x12, 0x0f, 0x01, 0x03, 0x6c, 0x00, 0x00, 0x1e}},
+		// LLDP-MED capabilities and inventory.
+		LLDPTLV{Type: 127, Value: []byte{0x00, 0x12, 0xbb, 0x01, 0x00, 0x33, 0x03}},
+		LLDPTLV{Type: 127, Value: []byte{0x00, 0x12, 0xbb, 0x08, 'S', 'N', '1'}},
+		LLDPTLV{Type: 127, Value: []byte{0x00, 0x12, 0xbb, 0x0a, 'p', 'h', 'o', 'n', 'e'}},
+	)
+
+	n, err := parseLLDPFrame(frame)
+	if err != nil {
+		t.Fatalf("failed to parse frame: %v", err)
+	}
+
+	want := testLLDPNeighbor
+	want.ManagementAddresses = []netip.Addr{netip.MustParseAddr("192.0.2.10")}
+	want.VLANNames = []LLDPVLANName{{ID: 10, Name: "data"}}
+	want.MAUType = 30
+	want.MED = &LLDPMED{
+		Capabilities: 0x33,
+		DeviceType:   3,
+		SerialNumber: "SN1",
+		Model:        "phone",
+	}
+
+	opts := []cmp.Option{
+		cmp.Comparer(addrEqual),
+		cmpopts.IgnoreFields(LLDPNeighbor{}, "TLVs"),
+	}
+
+	if diff := cmp.Diff(want, n, opts...); diff != "" {
+		t.Fatalf("unexpected neighbor (-want +got):\n%s", diff)
+	}
+
+	// The raw TLVs include every TLV except for the end TLV.
+	if diff := cmp.Diff(LLDPTLV{Type: 1, Value: testLLDPNeighbor.RawChassisID}, n.TLVs[0]); diff != "" {
+		t.Fatalf("unexpected first TLV (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(15, len(n.TLVs)); diff != "" {
+		t.Fatalf("unexpected number of TLVs (-want +got):\n%s", diff)
+	}
+}
+
+func TestWatchLLDPNeighbors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(file string, b []byte) {
+		t.Helper()
+
+		// Replace the file atomically, as networkd does.
+		tmp := filepath.Join(dir, "."+file)
+		if err := os.WriteFile(tmp, b, 0o644); err != nil {
+			t.Fatalf("failed to write neighbors: %v", err)
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, file)); err != nil {
+			t.Fatalf("failed to rename neighbors: %v", err)
+		}
+	}
+
+	write("2", testLLDPFile(testLLDPFrame()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := WatchLLDPNeighbors(ctx, dir, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to watch neighbors: %v", err)
+	}
+
+	// The initial neighbor is reported first, and then changed so that it
+	// advertises a VLAN name.
+	var got []LLDPNeighborEvent
+	got = append(got, <-events)
+
+	write("2", testLLDPFile(testLLDPFrame(
+		LLDPTLV{Type: 127, Value: []byte{0x00, 0x80, 0xc2, 0x03, 0x00, 0x0a, 0x04, 'd', 'a', 't', 'a'}},
+	)))
+	got = append(got, <-events)
+
+	if err := os.Remove(filepath.Join(dir, "2")); err != nil {
+		t.Fatalf("failed to remove neighbors: %v", err)
+	}
+	got = append(got, <-events)
+
+	changed := testLLDPNeighbor
+	changed.VLANNames = []LLDPVLANName{{ID: 10, Name: "data"}}
+
+	want := []LLDPNeighborEvent{
+		{Index: 2, Neighbor: testLLDPNeighbor},
+		{Index: 2, Neighbor: changed},
+		{Index: 2, Neighbor: changed, Removed: true},
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(LLDPNeighbor{}, "TLVs")); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	// A truncated file is read again on the next poll.
+	frame := testLLDPFile(testLLDPFrame())
+	write("2", frame[:len(frame)-1])
+
+	select {
+	case e, ok := <-events:
+		t.Fatalf("unexpected event: %+v, %v", e, ok)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	write("2", frame)
+
+	if diff := cmp.Diff(want[0], <-events, cmpopts.IgnoreFields(LLDPNeighbor{}, "TLVs")); diff != "" {
+		t.Fatalf("unexpected event (-want +got):\n%s", diff)
+	}
+
+	// A malformed file cannot be read again, so the watch ends.
+	write("2", testLLDPFile([]byte{0xff}))
+
+	select {
+	case e, ok := <-events:
+		if ok {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the watch to end")
+	}
+
+	cancel()
+
+	if _, err := WatchLLDPNeighbors(ctx, dir, 0); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestLLDPID(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []byte
+		s    string
+	}{
+		{
+			name: "short",
+			raw:  []byte{4},
+			s:    "04",
+		},
+		{
+			name: "MAC",
+			raw:  []byte{4, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+			s:    "00:11:22:33:44:55",
+		},
+		{
+			name: "IPv4",
+			raw:  []byte{5, 1, 192, 0, 2, 1},
+			s:    "192.0.2.1",
+		},
+		{
+			name: "IPv6",
+			raw:  append([]byte{5, 2, 0x20, 0x01, 0x0d, 0xb8}, make([]byte, 12)...),
+			s:    "2001:db8::",
+		},
+		{
+			name: "string",
+			raw:  []byte{7, 's', 'w'},
+			s:    "sw",
+		},
+		{
+			name: "binary",
+			raw:  []byte{7, 0x00, 0xff},
+			s:    "0700ff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.s, lldpID(tt.raw, 4, 5)); diff != "" {
+				t.Fatalf("unexpected ID (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// testLLDPNeighbor is the neighbor described by testLLDPFrame, which matches
+// the neighbor in the link-eth0.json fixture.
+var testLLDPNeighbor = LLDPNeighbor{
+	ChassisID:           "00:11:22:33:44:55",
+	RawChassisID:        []byte{4, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+	PortID:              "ge-0/0/1",
+	RawPortID:           []byte{5, 'g', 'e', '-', '0', '/', '0', '/', '1'},
+	PortDescription:     "uplink to server",
+	SystemName:          "switch01",
+	SystemDescription:   "Juniper Networks EX2300",
+	EnabledCapabilities: 20,
+	VLANID:              10,
+}
+
+// testLLDPFrame produces an Ethernet frame containing the TLVs of
+// testLLDPNeighbor, followed by any extra TLVs.
+func testLLDPFrame(extra ...LLDPTLV) []byte {
+	b := []byte{
+		// Destination and source MAC addresses, and EtherType.
+		0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e,
+		0x00, 0x11, 0x22, 0x33, 0x44, 0x55,
+		0x88, 0xcc,
+	}
+
+	tlv := func(typ uint8, value ...byte) {
+		b = binary.BigEndian.AppendUint16(b, uint16(typ)<<9|uint16(len(value)))
+		b = append(b, value...)
+	}
+
+	n := testLLDPNeighbor
+	tlv(1, n.RawChassisID...)
+	tlv(2, n.RawPortID...)
+	tlv(3, 0x00, 0x78)
+	tlv(4, []byte(n.PortDescription)...)
+	tlv(5, []byte(n.SystemName)...)
+	tlv(6, []byte(n.SystemDescription)...)
+	tlv(7, 0x00, 0x14, 0x00, 0x14)
+	tlv(127, 0x00, 0x80, 0xc2, 0x01, 0x00, 0x0a)
+	for _, t := range extra {
+		tlv(t.Type, t.Value...)
+	}
+	tlv(0)
+
+	return b
+}
+
+// testLLDPFile produces the contents of a networkd LLDP neighbor file with the
+// input frames.
+func testLLDPFile(frames ...[]byte) []byte {
+	var b []byte
+	for _, f := range frames {
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(f)))
+		b = append(b, f...)
+	}
+
+	return b
+}
@@ -0,0 +1,120 @@
+package networkd
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+// An NDiscConfig is the configuration which networkd has learned from IPv6
+// Router Advertisements on a link.
+type NDiscConfig struct {
+	// Routers are the default gateways advertised on the link.
+	Routers []NDiscRouter
+
+	// DNS and Domains are the recursive DNS servers (RDNSS) and DNS search
+	// list (DNSSL) advertised on the link.
+	DNS     []netip.Addr
+	Domains []string
+
+	// Prefixes are the prefixes used for stateless address autoconfiguration
+	// on the link.
+	Prefixes []NDiscPrefix
+}
+
+// An NDiscRouter is a default gateway learned from a Router Advertisement.
+type NDiscRouter struct {
+	Addr netip.Addr
+
+	// Expires is the time at which the router's default route expires, or
+	// the zero time.Time if it does not expire.
+	Expires time.Time
+}
+
+// An NDiscPrefix is an autoconfiguration prefix learned from a Router
+// Advertisement.
+type NDiscPrefix struct {
+	Prefix netip.Prefix
+
+	// PreferredUntil and ValidUntil are the times at which addresses within
+	// the prefix are no longer preferred or valid. Each is the zero
+	// time.Time if the lifetime is infinite.
+	PreferredUntil, ValidUntil time.Time
+}
+
+// NDisc returns the configuration networkd has learned from IPv6 Router
+// Advertisements on this link.
+func (ls *LinkService) NDisc(ctx context.Context) (*NDiscConfig, error) {
+	b, err := ls.DescribeRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jd, err := decodeLinkDescription(b)
+	if err != nil {
+		return nil, err
+	}
+
+	clk, err := newBootClock()
+	if err != nil {
+		return nil, err
+	}
+
+	return jd.ndisc(clk), nil
+}
+
+// ndisc produces an NDiscConfig from the configuration attributed to NDisc in
+// a link description.
+func (jd *jsonLinkDescription) ndisc(clk bootClock) *NDiscConfig {
+	const source = ConfigNDisc
+
+	var nc NDiscConfig
+	for _, r := range jd.Routes {
+		if r.ConfigSource != source || r.DestinationPrefixLength != 0 {
+			continue
+		}
+
+		nc.Routers = append(nc.Routers, NDiscRouter{
+			Addr:    netip.Addr(r.Gateway),
+			Expires: clk.Time(r.LifetimeUSec),
+		})
+	}
+
+	for _, d := range jd.DNS {
+		if d.ConfigSource == source {
+			nc.DNS = append(nc.DNS, netip.Addr(d.Address))
+		}
+	}
+
+	for _, d := range jd.SearchDomains {
+		if d.ConfigSource == source {
+			nc.Domains = append(nc.Domains, d.Domain)
+		}
+	}
+
+	for _, a := range jd.Addresses {
+		if a.ConfigSource != source {
+			continue
+		}
+
+		p, err := netip.Addr(a.Address).Prefix(a.PrefixLength)
+		if err != nil {
+			// Skip addresses with an invalid prefix length.
+			continue
+		}
+
+		// Multiple addresses may be autoconfigured from the same prefix.
+		if slices.ContainsFunc(nc.Prefixes, func(np NDiscPrefix) bool { return np.Prefix == p }) {
+			continue
+		}
+
+		nc.Prefixes = append(nc.Prefixes, NDiscPrefix{
+			Prefix:         p,
+			PreferredUntil: clk.Time(a.PreferredLifetimeUSec),
+			ValidUntil:     clk.Time(a.ValidLifetimeUSec),
+		})
+	}
+
+	return &nc
+}
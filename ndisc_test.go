@@ -0,0 +1,56 @@
+package networkd
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLinkDescriptionNDisc(t *testing.T) {
+	jd, err := decodeLinkDescription([]byte(testFixture(t, "link-eth0.json")))
+	if err != nil {
+		t.Fatalf("failed to decode link description: %v", err)
+	}
+
+	// Pretend the system booted exactly at the Unix epoch so boot timestamps
+	// are easy to verify.
+	clk := bootClock{now: time.Unix(1000, 0), boot: 1000 * time.Second}
+
+	want := &NDiscConfig{
+		Routers: []NDiscRouter{{
+			Addr:    netip.MustParseAddr("fe80::1"),
+			Expires: time.Unix(1900, 0),
+		}},
+		DNS:     []netip.Addr{netip.MustParseAddr("2001:db8::1")},
+		Domains: []string{"lan.example.com"},
+		Prefixes: []NDiscPrefix{{
+			Prefix:         netip.MustParsePrefix("2001:db8::/64"),
+			PreferredUntil: time.Unix(14500, 0),
+			ValidUntil:     time.Unix(2592100, 0),
+		}},
+	}
+
+	if diff := cmp.Diff(want, jd.ndisc(clk), cmp.Comparer(addrEqual), cmp.Comparer(prefixEqual)); diff != "" {
+		t.Fatalf("unexpected NDisc configuration (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkDescriptionNDiscInvalidPrefix(t *testing.T) {
+	jd, err := decodeLinkDescription([]byte(`{
+		"Addresses": [{
+			"Family": 10,
+			"Address": [32, 1, 13, 184, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1],
+			"PrefixLength": 129,
+			"ConfigSource": "NDisc"
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("failed to decode link description: %v", err)
+	}
+
+	if diff := cmp.Diff(&NDiscConfig{}, jd.ndisc(testBootClock)); diff != "" {
+		t.Fatalf("unexpected NDisc configuration (-want +got):\n%s", diff)
+	}
+}
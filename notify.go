@@ -0,0 +1,75 @@
+package networkd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// WaitOnlineNotify calls WaitOnline with opts and, for a Type=notify systemd
+// service, reports its progress to the service manager using the sd_notify
+// protocol. While WaitOnline blocks, STATUS= describes the links it is waiting
+// on, and once the system is online, READY=1 declares the service ready.
+//
+// If the NOTIFY_SOCKET environment variable is not set, WaitOnlineNotify is
+// equivalent to WaitOnline.
+func (c *Client) WaitOnlineNotify(ctx context.Context, opts *WaitOnlineOptions) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return c.WaitOnline(ctx, opts)
+	}
+
+	var o WaitOnlineOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	progress := o.Progress
+	o.Progress = func(blocking []BlockingLink) {
+		// Status updates are best effort, but readiness is not.
+		_ = notify(socket, "STATUS="+waitingStatus(blocking))
+		if progress != nil {
+			progress(blocking)
+		}
+	}
+
+	if err := c.WaitOnline(ctx, &o); err != nil {
+		return err
+	}
+
+	if err := notify(socket, "READY=1\nSTATUS=Network is online"); err != nil {
+		return fmt.Errorf("notify service manager of readiness: %w", err)
+	}
+
+	return nil
+}
+
+// waitingStatus produces an sd_notify status string from the links blocking
+// WaitOnline.
+func waitingStatus(blocking []BlockingLink) string {
+	if len(blocking) == 0 {
+		return "Waiting for any link to come online"
+	}
+
+	ss := make([]string, 0, len(blocking))
+	for _, b := range blocking {
+		ss = append(ss, fmt.Sprintf("%s: %s", b.Name, b.Reason))
+	}
+
+	return "Waiting for network: " + strings.Join(ss, "; ")
+}
+
+// notify sends state to the sd_notify socket at path. A leading '@' denotes a
+// socket in the abstract namespace.
+func notify(path, state string) error {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
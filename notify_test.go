@@ -0,0 +1,73 @@
+package networkd
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientWaitOnlineNotify(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	props := testLinkProperties()
+	props["AdministrativeState"] = dbus.MakeVariant("configuring")
+
+	c, signals, active := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     props,
+	})
+
+	var progress int
+	opts := &WaitOnlineOptions{
+		Progress: func([]BlockingLink) { progress++ },
+	}
+
+	errC := make(chan error)
+	go func() { errC <- c.WaitOnlineNotify(context.Background(), opts) }()
+
+	testWaitWatches(t, active, 1)
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "AdministrativeState", "configured")
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to wait online: %v", err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+
+	var got []string
+	b := make([]byte, 256)
+	for i := 0; i < 2; i++ {
+		n, err := conn.Read(b)
+		if err != nil {
+			t.Fatalf("failed to read notification: %v", err)
+		}
+
+		got = append(got, string(b[:n]))
+	}
+
+	want := []string{
+		`STATUS=Waiting for network: eth0: administrative state is "configuring"`,
+		"READY=1\nSTATUS=Network is online",
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected notifications (-want +got):\n%s", diff)
+	}
+	if progress != 1 {
+		t.Fatalf("expected 1 progress update, but got %d", progress)
+	}
+}
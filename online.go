@@ -0,0 +1,380 @@
+package networkd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WaitOnlineOptions specify options for Client.WaitOnline. A nil
+// *WaitOnlineOptions or the zero value applies the same defaults as
+// systemd-networkd-wait-online.
+type WaitOnlineOptions struct {
+	// MinOperationalState and MaxOperationalState, if set, are the bounds of
+	// the range of operational states in which each link is considered
+	// online, as with the --operational-state flag. Each overrides the
+	// corresponding bound of the RequiredOperationalStateForOnline setting of
+	// each link's .network file.
+	MinOperationalState OperationalState
+	MaxOperationalState OperationalState
+
+	// Family, if set, is the address family which each link must have
+	// configured to be considered online, as with the --ipv4 and --ipv6 flags.
+	// It overrides the RequiredFamilyForOnline setting of each link's .network
+	// file.
+	Family AddressFamily
+
+	// Interfaces, if set, restricts WaitOnline to the links with these names
+	// or alternative names, as with the --interface flag. These links are
+	// waited for even if they do not yet exist, are not managed by networkd,
+	// or are not required for online. Each link must reach the operational
+	// state to which its name is mapped, or if that state is empty, the
+	// minimum state which would otherwise apply.
+	Interfaces map[string]OperationalState
+
+	// Ignore, if set, causes WaitOnline to ignore links whose names match at
+	// least one of the glob patterns, as used by path.Match, such as "veth*"
+	// or "docker*". It corresponds to the --ignore flag.
+	Ignore []string
+
+	// Any, if set, causes WaitOnline to return as soon as any of the links
+	// which would otherwise be waited for is online, as with the --any flag.
+	Any bool
+
+	// Progress, if set, is called with the links which are blocking WaitOnline
+	// each time they change. An empty slice indicates that no link blocks
+	// WaitOnline, but no link is online yet. Progress is called synchronously
+	// and must not block.
+	Progress func(blocking []BlockingLink)
+}
+
+// A BlockingLink is a link which prevents WaitOnline from returning.
+type BlockingLink struct {
+	// The link's index and name. Index is 0 if a link requested by
+	// WaitOnlineOptions.Interfaces does not exist.
+	Index int
+	Name  string
+
+	// Reason describes why the link is not yet online.
+	Reason string
+}
+
+// WaitOnline blocks until the system is online, or until ctx is canceled.
+// WaitOnline is driven by D-Bus PropertiesChanged signals rather than polling.
+//
+// As with systemd-networkd-wait-online, the system is online when each link
+// managed by networkd and required for online has reached its required
+// operational state, and at least one link is online. Loopback links and links
+// which are unmanaged or not required for online are ignored.
+func (c *Client) WaitOnline(ctx context.Context, opts *WaitOnlineOptions) error {
+	return c.waitOnline(ctx, opts, time.Second)
+}
+
+// waitOnline implements WaitOnline, describing links which could not be
+// described again after the retry interval.
+func (c *Client) waitOnline(ctx context.Context, opts *WaitOnlineOptions, retry time.Duration) error {
+	if opts == nil {
+		opts = &WaitOnlineOptions{}
+	}
+	for _, p := range opts.Ignore {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid ignored link name pattern %q: %w", p, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Ignored links are not tracked at all.
+	initial, events, err := c.Manager.watchLinks(ctx, func(l Link) bool { return !opts.ignored(l) })
+	if err != nil {
+		return fmt.Errorf("wait online: %w", err)
+	}
+	defer func() {
+		// Stop watching links and wait for the subscription to end.
+		cancel()
+		for range events {
+		}
+	}()
+
+	links := make(map[int]*onlineLink, len(initial))
+	for _, wl := range initial {
+		links[wl.l.Index] = c.onlineLink(ctx, wl.l, wl.props)
+	}
+
+	var prev []BlockingLink
+	for {
+		online, blocking := opts.online(links)
+		if online {
+			return nil
+		}
+
+		if opts.Progress != nil && (prev == nil || !slices.Equal(prev, blocking)) {
+			opts.Progress(blocking)
+			prev = blocking
+		}
+
+		// Describing a link may fail transiently, so try again shortly even
+		// if the link's properties don't change.
+		var retryC <-chan time.Time
+		for _, ol := range links {
+			if ol.tracked && ol.desc == nil {
+				retryC = time.After(retry)
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait online: %w", ctx.Err())
+		case e, ok := <-events:
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return fmt.Errorf("wait online: %w", err)
+				}
+
+				return fmt.Errorf("wait online: %w", errConnClosed)
+			}
+
+			switch e.Kind {
+			case EventLinkAdded:
+				links[e.Link.Index] = c.onlineLink(ctx, e.Link, e.New)
+			case EventLinkRemoved:
+				delete(links, e.Link.Index)
+			case EventLinkChanged:
+				ol, ok := links[e.Link.Index]
+				if !ok {
+					links[e.Link.Index] = c.onlineLink(ctx, e.Link, e.New)
+					continue
+				}
+
+				props, _ := e.New.(LinkProperties)
+				if props.AdministrativeState != ol.props.AdministrativeState || ol.desc == nil {
+					// networkd applies a link's .network file, and therefore
+					// its online requirements, as the link is configured.
+					// Links which could not be described are also retried.
+					ol.describe(ctx, c)
+				}
+				ol.props = props
+			}
+		case <-retryC:
+			for _, ol := range links {
+				if ol.tracked && ol.desc == nil {
+					ol.describe(ctx, c)
+				}
+			}
+		}
+	}
+}
+
+// An onlineLink is the state of a link considered by WaitOnline.
+type onlineLink struct {
+	l       Link
+	props   LinkProperties
+	tracked bool
+
+	// The link's description, or nil if it could not be fetched.
+	desc *jsonLinkDescription
+}
+
+// onlineLink produces an onlineLink for l with the given properties, which are
+// nil if the link is not tracked.
+func (c *Client) onlineLink(ctx context.Context, l Link, props Properties) *onlineLink {
+	lp, ok := props.(LinkProperties)
+
+	ol := &onlineLink{l: l, props: lp, tracked: ok}
+	if ok {
+		ol.describe(ctx, c)
+	}
+
+	return ol
+}
+
+// describe fetches the description of ol's link. Errors are ignored since the
+// link may have been removed in the meantime, in which case its removal will
+// be reported shortly.
+func (ol *onlineLink) describe(ctx context.Context, c *Client) {
+	b, err := c.Link(ol.l).DescribeRaw(ctx)
+	if err != nil {
+		ol.desc = nil
+		return
+	}
+
+	ol.desc, _ = decodeLinkDescription(b)
+}
+
+// A linkStatus is the result of evaluating whether a link is online.
+type linkStatus int
+
+// Possible linkStatus values.
+const (
+	linkIgnored linkStatus = iota
+	linkOffline
+	linkOnline
+)
+
+// online reports whether the system is online according to o. If not, it also
+// returns the links which block the system from being online.
+func (o *WaitOnlineOptions) online(links map[int]*onlineLink) (bool, []BlockingLink) {
+	blocking := make([]BlockingLink, 0)
+	if !o.Any {
+		// Each of the requested interfaces must exist.
+		for name := range o.Interfaces {
+			var found bool
+			for _, ol := range links {
+				if ol.named(name) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				blocking = append(blocking, BlockingLink{Name: name, Reason: "link does not exist"})
+			}
+		}
+	}
+
+	var anyOnline bool
+	for _, ol := range links {
+		switch status, reason := o.link(ol); status {
+		case linkOffline:
+			blocking = append(blocking, BlockingLink{
+				Index:  ol.l.Index,
+				Name:   ol.l.Name,
+				Reason: reason,
+			})
+		case linkOnline:
+			anyOnline = true
+		}
+	}
+
+	if anyOnline && (o.Any || len(blocking) == 0) {
+		return true, nil
+	}
+
+	slices.SortFunc(blocking, func(a, b BlockingLink) int {
+		if a.Index != b.Index {
+			return a.Index - b.Index
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return false, blocking
+}
+
+// link evaluates whether ol is online according to o. If ol is offline, it
+// also returns the reason why.
+func (o *WaitOnlineOptions) link(ol *onlineLink) (linkStatus, string) {
+	if o.ignored(ol.l) {
+		return linkIgnored, ""
+	}
+
+	min, requested := o.interfaceState(ol)
+	if !requested && (len(o.Interfaces) > 0 || ol.props.AdministrativeState == AdministrativeUnmanaged) {
+		return linkIgnored, ""
+	}
+
+	// The link's requirements are not known until it can be described.
+	if ol.desc == nil {
+		return linkOffline, "link description is not available"
+	}
+	if !requested && (ol.desc.Type == "loopback" || !ol.desc.RequiredForOnline) {
+		return linkIgnored, ""
+	}
+
+	// Start with the link's own requirements, or networkd's default of
+	// degraded:routable, and apply any overrides.
+	r := OperationalStateRange{Min: OperationalDegraded, Max: OperationalRoutable}
+	if rs := ol.desc.RequiredOperationalStateForOnline; len(rs) == 2 {
+		r = OperationalStateRange{Min: rs[0], Max: rs[1]}
+	}
+	if o.MinOperationalState != "" {
+		r.Min = o.MinOperationalState
+	}
+	if o.MaxOperationalState != "" {
+		r.Max = o.MaxOperationalState
+	}
+	if min != "" {
+		r.Min = min
+	}
+
+	family := ol.desc.RequiredFamilyForOnline
+	if o.Family != "" {
+		family = o.Family
+	}
+
+	switch lp := ol.props; {
+	case lp.AdministrativeState != AdministrativeConfigured && lp.AdministrativeState != AdministrativeUnmanaged:
+		return linkOffline, fmt.Sprintf("administrative state is %q", lp.AdministrativeState)
+	case !lp.Ready(r.Min):
+		return linkOffline, fmt.Sprintf("operational state %q does not reach minimum %q", lp.OperationalState, r.Min)
+	case !r.Contains(lp.OperationalState):
+		return linkOffline, fmt.Sprintf("operational state %q exceeds maximum %q", lp.OperationalState, r.Max)
+	case !familyReady(lp, family, r.Min):
+		return linkOffline, fmt.Sprintf("%s addresses do not reach minimum %q", family, r.Min)
+	}
+
+	return linkOnline, ""
+}
+
+// familyReady reports whether lp has the addresses of family required to reach
+// the min operational state.
+func familyReady(lp LinkProperties, family AddressFamily, min OperationalState) bool {
+	var want AddressState
+	switch {
+	case min.AtLeast(OperationalRoutable):
+		want = AddressRoutable
+	case min.AtLeast(OperationalDegraded):
+		want = AddressDegraded
+	default:
+		// No addresses are required.
+		return true
+	}
+
+	switch family {
+	case FamilyIPv4:
+		return lp.IPv4AddressState.AtLeast(want)
+	case FamilyIPv6:
+		return lp.IPv6AddressState.AtLeast(want)
+	case FamilyBoth:
+		return lp.IPv4AddressState.AtLeast(want) && lp.IPv6AddressState.AtLeast(want)
+	default:
+		// Any family will do, which LinkProperties.Ready has verified.
+		return true
+	}
+}
+
+// ignored reports whether l matches one of o's ignored link name patterns.
+func (o *WaitOnlineOptions) ignored(l Link) bool {
+	for _, p := range o.Ignore {
+		if ok, _ := path.Match(p, l.Name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// interfaceState returns the operational state requested for ol by
+// o.Interfaces, and whether ol was requested at all.
+func (o *WaitOnlineOptions) interfaceState(ol *onlineLink) (OperationalState, bool) {
+	for name, state := range o.Interfaces {
+		if ol.named(name) {
+			return state, true
+		}
+	}
+
+	return "", false
+}
+
+// named reports whether ol has the name or alternative name name.
+func (ol *onlineLink) named(name string) bool {
+	if ol.l.Name == name {
+		return true
+	}
+
+	return ol.desc != nil && slices.Contains(ol.desc.AlternativeNames, name)
+}
@@ -0,0 +1,384 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientWaitOnline(t *testing.T) {
+	props := testLinkProperties()
+	props["AdministrativeState"] = dbus.MakeVariant("configuring")
+	props["OperationalState"] = dbus.MakeVariant("carrier")
+
+	c, signals, active := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     props,
+	})
+
+	errC := make(chan error)
+	go func() { errC <- c.WaitOnline(context.Background(), nil) }()
+
+	testWaitWatches(t, active, 1)
+
+	// eth0 must be both configured and at least degraded.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "AdministrativeState", "configured")
+
+	select {
+	case err := <-errC:
+		t.Fatalf("wait returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "routable")
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to wait online: %v", err)
+	}
+
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestClientWaitOnlineInterfaces(t *testing.T) {
+	props := testLinkProperties()
+	props["OperationalState"] = dbus.MakeVariant("degraded")
+
+	c, signals, active := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     props,
+	})
+
+	// eth0 is referred to by an alternative name, and loopback links are not
+	// ignored when requested explicitly.
+	opts := &WaitOnlineOptions{
+		Interfaces: map[string]OperationalState{
+			"lo":   OperationalCarrier,
+			"ens3": OperationalRoutable,
+		},
+	}
+
+	errC := make(chan error)
+	go func() { errC <- c.WaitOnline(context.Background(), opts) }()
+
+	testWaitWatches(t, active, 1)
+
+	select {
+	case err := <-errC:
+		t.Fatalf("wait returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "routable")
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to wait online: %v", err)
+	}
+}
+
+func TestClientWaitOnlineInterfacesMissing(t *testing.T) {
+	c, _, _ := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// eth0 is online, but br0 does not exist.
+	var got []BlockingLink
+	opts := &WaitOnlineOptions{
+		Interfaces: map[string]OperationalState{
+			"eth0": "",
+			"br0":  OperationalCarrier,
+		},
+		Progress: func(blocking []BlockingLink) { got = blocking },
+	}
+
+	if err := c.WaitOnline(ctx, opts); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, but got: %v", err)
+	}
+
+	want := []BlockingLink{{Name: "br0", Reason: "link does not exist"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected blocking links (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientWaitOnlineAny(t *testing.T) {
+	c, _, _ := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+
+	// eth0 is online, so the missing br0 does not block.
+	opts := &WaitOnlineOptions{
+		Interfaces: map[string]OperationalState{
+			"eth0": "",
+			"br0":  OperationalCarrier,
+		},
+		Any: true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.WaitOnline(ctx, opts); err != nil {
+		t.Fatalf("failed to wait online: %v", err)
+	}
+}
+
+func TestClientWaitOnlineOperationalStateRange(t *testing.T) {
+	c, signals, active := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+
+	// eth0 is routable, which exceeds the maximum state.
+	opts := &WaitOnlineOptions{
+		MinOperationalState: OperationalCarrier,
+		MaxOperationalState: OperationalDegraded,
+	}
+
+	errC := make(chan error)
+	go func() { errC <- c.WaitOnline(context.Background(), opts) }()
+
+	testWaitWatches(t, active, 1)
+
+	select {
+	case err := <-errC:
+		t.Fatalf("wait returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded")
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to wait online: %v", err)
+	}
+}
+
+func TestClientWaitOnlineFamily(t *testing.T) {
+	c, signals, active := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+
+	// eth0 is routable over IPv4, but only has link-local IPv6 addresses.
+	opts := &WaitOnlineOptions{
+		MinOperationalState: OperationalRoutable,
+		Family:              FamilyBoth,
+	}
+
+	errC := make(chan error)
+	go func() { errC <- c.WaitOnline(context.Background(), opts) }()
+
+	testWaitWatches(t, active, 1)
+
+	select {
+	case err := <-errC:
+		t.Fatalf("wait returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "IPv6AddressState", "routable")
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to wait online: %v", err)
+	}
+}
+
+func TestClientWaitOnlineIgnore(t *testing.T) {
+	props := testLinkProperties()
+	props["OperationalState"] = dbus.MakeVariant("no-carrier")
+
+	c, _, _ := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     props,
+	})
+
+	// lo is required explicitly and is online, and eth0 is offline but
+	// ignored.
+	opts := &WaitOnlineOptions{
+		Interfaces: map[string]OperationalState{"lo": OperationalCarrier},
+		Ignore:     []string{"eth*"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.WaitOnline(ctx, opts); err != nil {
+		t.Fatalf("failed to wait online: %v", err)
+	}
+
+	opts = &WaitOnlineOptions{Ignore: []string{"["}}
+	if err := c.WaitOnline(ctx, opts); !errors.Is(err, path.ErrBadPattern) {
+		t.Fatalf("expected bad pattern error, but got: %v", err)
+	}
+}
+
+func TestClientWaitOnlineProgress(t *testing.T) {
+	props := testLinkProperties()
+	props["AdministrativeState"] = dbus.MakeVariant("configuring")
+	props["OperationalState"] = dbus.MakeVariant("carrier")
+
+	c, signals, active := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     props,
+	})
+
+	var got [][]BlockingLink
+	opts := &WaitOnlineOptions{
+		Progress: func(blocking []BlockingLink) { got = append(got, blocking) },
+	}
+
+	errC := make(chan error)
+	go func() { errC <- c.WaitOnline(context.Background(), opts) }()
+
+	testWaitWatches(t, active, 1)
+
+	// Unrelated changes which do not affect the blocking links are not
+	// reported.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "CarrierState", "carrier")
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "AdministrativeState", "configured")
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "routable")
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to wait online: %v", err)
+	}
+
+	want := [][]BlockingLink{
+		{{Index: 2, Name: "eth0", Reason: `administrative state is "configuring"`}},
+		{{Index: 2, Name: "eth0", Reason: `operational state "carrier" does not reach minimum "degraded"`}},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected progress (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientWaitOnlineCanceled(t *testing.T) {
+	props := testLinkProperties()
+	props["OperationalState"] = dbus.MakeVariant("no-carrier")
+
+	c, _, _ := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     props,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := c.WaitOnline(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, but got: %v", err)
+	}
+}
+
+func TestClientWaitOnlineDescribeRetry(t *testing.T) {
+	tests := []struct {
+		name   string
+		retry  time.Duration
+		signal bool
+	}{
+		{name: "backoff", retry: 10 * time.Millisecond},
+		{name: "property change", retry: time.Hour, signal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, signals, active := testOnlineClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+				testLinks[0].ObjectPath: testLinkProperties(),
+				testLink.ObjectPath:     testLinkProperties(),
+			})
+
+			// eth0 is online, but cannot be described at first.
+			var failed atomic.Bool
+			call := c.call
+			c.call = func(ctx context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
+				if method == interfacePath("Link", "Describe") && op == testLink.ObjectPath && failed.CompareAndSwap(false, true) {
+					return dbus.Error{Name: "org.freedesktop.DBus.Error.NoReply"}
+				}
+
+				return call(ctx, service, method, op, out, args...)
+			}
+
+			errC := make(chan error)
+			go func() { errC <- c.waitOnline(context.Background(), nil, tt.retry) }()
+
+			testWaitWatches(t, active, 1)
+
+			if tt.signal {
+				signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "CarrierState", "carrier")
+			}
+
+			select {
+			case err := <-errC:
+				if err != nil {
+					t.Fatalf("failed to wait online: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for link description to be retried")
+			}
+
+			if !failed.Load() {
+				t.Fatal("link description did not fail")
+			}
+		})
+	}
+}
+
+// testOnlineClient produces a Client which serves the properties of each link
+// in objects, the loopback and eth0 links' descriptions, and ListLinks from
+// testLinks.
+func testOnlineClient(t *testing.T, objects map[dbus.ObjectPath]map[string]dbus.Variant) (*Client, chan<- *dbus.Signal, *atomic.Int32) {
+	t.Helper()
+
+	c, signals, active := testWatchClient(t, objects)
+	_ = testDynamicLinks(t, c)
+
+	var (
+		lo   = `{"Index":1,"Name":"lo","Type":"loopback"}`
+		eth0 = testFixture(t, "link-eth0.json")
+	)
+
+	manager := c.call
+	c.call = func(ctx context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
+		if method != interfacePath("Link", "Describe") {
+			return manager(ctx, service, method, op, out, args...)
+		}
+
+		switch op {
+		case testLinks[0].ObjectPath:
+			*out.(*string) = lo
+		case testLink.ObjectPath:
+			*out.(*string) = eth0
+		default:
+			return dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownObject"}
+		}
+
+		return nil
+	}
+
+	return c, signals, active
+}
+
+// testWaitWatches blocks until n watches are active.
+func testWaitWatches(t *testing.T, active *atomic.Int32, n int32) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for active.Load() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d watches, got %d", n, active.Load())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
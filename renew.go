@@ -0,0 +1,71 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RenewAll concurrently renews the DHCPv4 leases of every link which is
+// managed by networkd and configured by its DHCPv4 client. Links which
+// disappear before they can be renewed are skipped. If renewal fails for any
+// other links, the returned error aggregates each link's error and may be
+// inspected using errors.Is and errors.As.
+func (c *Client) RenewAll(ctx context.Context) error {
+	b, err := c.Manager.DescribeRaw(ctx)
+	if err != nil {
+		return err
+	}
+
+	jd, err := decodeNetworkDescription(b)
+	if err != nil {
+		return err
+	}
+
+	// Bound the number of concurrent requests so that hosts with many links
+	// don't flood the bus.
+	const concurrency = 16
+
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, concurrency)
+		errs = make([]error, len(jd.Interfaces))
+	)
+
+	for i, l := range jd.Interfaces {
+		if !l.renewable() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i, index int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			err := c.Manager.RenewLink(ctx, index)
+			switch {
+			case errors.Is(err, os.ErrNotExist), isUnknownObject(err):
+				// The link disappeared after it was described.
+			case err != nil:
+				errs[i] = fmt.Errorf("link %q: %w", name, err)
+			}
+		}(i, l.Index, l.Name)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// renewable reports whether a link is managed by networkd and has an active
+// DHCPv4 client.
+func (jd *jsonLinkDescription) renewable() bool {
+	switch jd.AdministrativeState {
+	case AdministrativeConfiguring, AdministrativeConfigured:
+		return jd.DHCPv4Client != nil
+	default:
+		return false
+	}
+}
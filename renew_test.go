@@ -0,0 +1,122 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientRenewAll(t *testing.T) {
+	const description = `{"Interfaces":[
+		{"Index":1,"Name":"lo","AdministrativeState":"unmanaged"},
+		{"Index":2,"Name":"eth0","AdministrativeState":"configured","DHCPv4Client":{}},
+		{"Index":3,"Name":"eth1","AdministrativeState":"configured"},
+		{"Index":4,"Name":"eth2","AdministrativeState":"configuring","DHCPv4Client":{}},
+		{"Index":5,"Name":"eth3","AdministrativeState":"failed","DHCPv4Client":{}}
+	]}`
+
+	var (
+		mu      sync.Mutex
+		renewed []int
+	)
+
+	c := testManagerClient(t, func(method string, args []any) []any {
+		switch method {
+		case "Describe":
+			return []any{description}
+		case "RenewLink":
+			mu.Lock()
+			defer mu.Unlock()
+
+			index := int(args[0].(int32))
+			renewed = append(renewed, index)
+
+			// Pretend eth2 disappeared before it could be renewed.
+			if index == 4 {
+				return nil
+			}
+
+			return []any{}
+		default:
+			t.Fatalf("unexpected method: %q", method)
+			return nil
+		}
+	})
+
+	// Links which disappear are skipped.
+	if err := c.RenewAll(context.Background()); err != nil {
+		t.Fatalf("failed to renew all: %v", err)
+	}
+
+	sort.Ints(renewed)
+	if diff := cmp.Diff([]int{2, 4}, renewed); diff != "" {
+		t.Fatalf("unexpected renewed links (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientRenewAllBounded(t *testing.T) {
+	// Describe many renewable links, one of which cannot be renewed.
+	const n = 40
+
+	var b strings.Builder
+	b.WriteString(`{"Interfaces":[`)
+	for i := 1; i <= n; i++ {
+		if i > 1 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"Index":%d,"Name":"eth%d","AdministrativeState":"configured","DHCPv4Client":{}}`, i, i)
+	}
+	b.WriteString("]}")
+
+	var active, peak atomic.Int32
+	c := testManagerClient(t, func(method string, args []any) []any {
+		switch method {
+		case "Describe":
+			return []any{b.String()}
+		case "RenewLink":
+			n := active.Add(1)
+			defer active.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+
+			time.Sleep(time.Millisecond)
+			return []any{}
+		default:
+			t.Fatalf("unexpected method: %q", method)
+			return nil
+		}
+	})
+
+	errDenied := dbus.Error{Name: "org.freedesktop.DBus.Error.AccessDenied"}
+	call := c.call
+	c.call = func(ctx context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
+		if strings.HasSuffix(method, ".RenewLink") && args[0].(int32) == 7 {
+			return errDenied
+		}
+
+		return call(ctx, service, method, op, out, args...)
+	}
+
+	err := c.RenewAll(context.Background())
+	var derr dbus.Error
+	if !errors.As(err, &derr) || derr.Name != errDenied.Name {
+		t.Fatalf("expected access denied error, but got: %v", err)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("unexpected not exist error: %v", err)
+	}
+
+	if p := peak.Load(); p > 16 {
+		t.Fatalf("too many concurrent renewals: %d", p)
+	}
+}
@@ -0,0 +1,149 @@
+package networkd
+
+import "slices"
+
+// An AdministrativeState is networkd's setup state for a link.
+type AdministrativeState string
+
+// Possible AdministrativeState values.
+const (
+	// AdministrativePending indicates that udev is still processing the link.
+	AdministrativePending AdministrativeState = "pending"
+
+	// AdministrativeInitialized indicates that udev has processed the link,
+	// but networkd has not yet started configuring it.
+	AdministrativeInitialized AdministrativeState = "initialized"
+
+	// AdministrativeConfiguring indicates that networkd is configuring the
+	// link.
+	AdministrativeConfiguring AdministrativeState = "configuring"
+
+	// AdministrativeConfigured indicates that networkd has successfully
+	// configured the link.
+	AdministrativeConfigured AdministrativeState = "configured"
+
+	// AdministrativeUnmanaged indicates that networkd does not manage the
+	// link.
+	AdministrativeUnmanaged AdministrativeState = "unmanaged"
+
+	// AdministrativeFailed indicates that networkd failed to configure the
+	// link.
+	AdministrativeFailed AdministrativeState = "failed"
+
+	// AdministrativeLinger indicates that the link is gone, but networkd has
+	// not yet dropped its state.
+	AdministrativeLinger AdministrativeState = "linger"
+)
+
+// An OperationalState is the operational state of a link, or of the system as
+// a whole. OperationalStates are ordered from least to most operational.
+type OperationalState string
+
+// Possible OperationalState values, in increasing order.
+const (
+	OperationalMissing         OperationalState = "missing"
+	OperationalOff             OperationalState = "off"
+	OperationalNoCarrier       OperationalState = "no-carrier"
+	OperationalDormant         OperationalState = "dormant"
+	OperationalDegradedCarrier OperationalState = "degraded-carrier"
+	OperationalCarrier         OperationalState = "carrier"
+	OperationalDegraded        OperationalState = "degraded"
+	OperationalEnslaved        OperationalState = "enslaved"
+	OperationalRoutable        OperationalState = "routable"
+)
+
+// operationalStates is the ordered list of OperationalStates.
+var operationalStates = []OperationalState{
+	OperationalMissing,
+	OperationalOff,
+	OperationalNoCarrier,
+	OperationalDormant,
+	OperationalDegradedCarrier,
+	OperationalCarrier,
+	OperationalDegraded,
+	OperationalEnslaved,
+	OperationalRoutable,
+}
+
+// AtLeast reports whether s is at least as operational as min. Unknown states
+// are never at least min.
+func (s OperationalState) AtLeast(min OperationalState) bool {
+	return atLeast(operationalStates, s, min)
+}
+
+// A CarrierState is the carrier state of a link, or of the system as a whole.
+// CarrierStates are ordered from least to most operational.
+type CarrierState string
+
+// Possible CarrierState values, in increasing order.
+const (
+	CarrierOff             CarrierState = "off"
+	CarrierNoCarrier       CarrierState = "no-carrier"
+	CarrierDormant         CarrierState = "dormant"
+	CarrierDegradedCarrier CarrierState = "degraded-carrier"
+	CarrierCarrier         CarrierState = "carrier"
+	CarrierEnslaved        CarrierState = "enslaved"
+)
+
+// carrierStates is the ordered list of CarrierStates.
+var carrierStates = []CarrierState{
+	CarrierOff,
+	CarrierNoCarrier,
+	CarrierDormant,
+	CarrierDegradedCarrier,
+	CarrierCarrier,
+	CarrierEnslaved,
+}
+
+// AtLeast reports whether s is at least as operational as min. Unknown states
+// are never at least min.
+func (s CarrierState) AtLeast(min CarrierState) bool {
+	return atLeast(carrierStates, s, min)
+}
+
+// An AddressState is the address state of a link, or of the system as a
+// whole. AddressStates are ordered from least to most operational.
+type AddressState string
+
+// Possible AddressState values, in increasing order.
+const (
+	AddressOff      AddressState = "off"
+	AddressDegraded AddressState = "degraded"
+	AddressRoutable AddressState = "routable"
+)
+
+// addressStates is the ordered list of AddressStates.
+var addressStates = []AddressState{
+	AddressOff,
+	AddressDegraded,
+	AddressRoutable,
+}
+
+// AtLeast reports whether s is at least as operational as min. Unknown states
+// are never at least min.
+func (s AddressState) AtLeast(min AddressState) bool {
+	return atLeast(addressStates, s, min)
+}
+
+// An OnlineState is the online state of a link, or of the system as a whole.
+type OnlineState string
+
+// Possible OnlineState values.
+const (
+	// OnlineOffline indicates that no required links are online.
+	OnlineOffline OnlineState = "offline"
+
+	// OnlinePartial indicates that some, but not all, required links are
+	// online.
+	OnlinePartial OnlineState = "partial"
+
+	// OnlineOnline indicates that all required links are online.
+	OnlineOnline OnlineState = "online"
+)
+
+// atLeast reports whether s is ordered at or after min in states. If either
+// state is unknown, atLeast returns false.
+func atLeast[T comparable](states []T, s, min T) bool {
+	si, mi := slices.Index(states, s), slices.Index(states, min)
+	return si != -1 && mi != -1 && si >= mi
+}
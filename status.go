@@ -0,0 +1,115 @@
+package networkd
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+)
+
+// A Status is a summary of the state of systemd-networkd and its links, similar
+// to the output of `networkctl status`.
+type Status struct {
+	// Manager contains the system-wide state.
+	Manager ManagerProperties
+
+	// Links contains the state of each link known to networkd.
+	Links []LinkStatus
+}
+
+// A LinkStatus is a summary of the state of a single link.
+type LinkStatus struct {
+	Link
+
+	// Type is the link's type, such as "ether" or "loopback".
+	Type string
+
+	AdministrativeState AdministrativeState
+	OperationalState    OperationalState
+	CarrierState        CarrierState
+	AddressState        AddressState
+	OnlineState         OnlineState
+
+	// Addresses, Gateways, and DNS are the IP addresses, default gateways,
+	// and DNS servers currently configured on the link.
+	Addresses []netip.Prefix
+	Gateways  []netip.Addr
+	DNS       []netip.Addr
+}
+
+// Status fetches a summary of the state of systemd-networkd and its links.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	props, err := c.Manager.Properties(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := c.Manager.ListLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := c.Manager.DescribeRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jd, err := decodeNetworkDescription(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStatus(props, links, jd), nil
+}
+
+// newStatus produces a Status from the Manager properties, list of links, and
+// networkd's description of each link.
+func newStatus(props ManagerProperties, links []Link, jd *jsonNetworkDescription) *Status {
+	s := &Status{
+		Manager: props,
+		Links:   make([]LinkStatus, 0, len(links)),
+	}
+
+	for _, l := range links {
+		// Links may appear or disappear between the list and describe calls,
+		// so only report links present in both.
+		i := slices.IndexFunc(jd.Interfaces, func(jl jsonLinkDescription) bool {
+			return jl.Index == l.Index
+		})
+		if i == -1 {
+			continue
+		}
+
+		s.Links = append(s.Links, jd.Interfaces[i].status(l))
+	}
+
+	return s
+}
+
+// status produces a LinkStatus for l from its JSON link description.
+func (jd *jsonLinkDescription) status(l Link) LinkStatus {
+	ls := LinkStatus{
+		Link:                l,
+		Type:                jd.Type,
+		AdministrativeState: jd.AdministrativeState,
+		OperationalState:    jd.OperationalState,
+		CarrierState:        jd.CarrierState,
+		AddressState:        jd.AddressState,
+		OnlineState:         jd.OnlineState,
+	}
+
+	for _, a := range jd.Addresses {
+		ls.Addresses = append(ls.Addresses, netip.PrefixFrom(netip.Addr(a.Address), a.PrefixLength))
+	}
+
+	for _, r := range jd.Routes {
+		if r.DestinationPrefixLength == 0 && netip.Addr(r.Gateway).IsValid() {
+			ls.Gateways = append(ls.Gateways, netip.Addr(r.Gateway))
+		}
+	}
+
+	for _, d := range jd.DNS {
+		ls.DNS = append(ls.DNS, netip.Addr(d.Address))
+	}
+
+	return ls
+}
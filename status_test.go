@@ -0,0 +1,59 @@
+package networkd
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewStatus(t *testing.T) {
+	jd, err := decodeNetworkDescription([]byte(
+		`{"Interfaces":[` + testFixture(t, "link-eth0.json") + `,{"Index":4,"Name":"gone"}]}`,
+	))
+	if err != nil {
+		t.Fatalf("failed to decode description: %v", err)
+	}
+
+	props := ManagerProperties{
+		OperationalState: OperationalRoutable,
+		OnlineState:      OnlineOnline,
+	}
+
+	// The loopback link is not present in the description and the "gone" link
+	// is not present in the list, so only eth0 is reported.
+	got := newStatus(props, testLinks, jd)
+
+	want := &Status{
+		Manager: props,
+		Links: []LinkStatus{{
+			Link:                testLink,
+			Type:                "ether",
+			AdministrativeState: AdministrativeConfigured,
+			OperationalState:    OperationalRoutable,
+			CarrierState:        CarrierCarrier,
+			AddressState:        AddressRoutable,
+			OnlineState:         OnlineOnline,
+			Addresses: []netip.Prefix{
+				netip.MustParsePrefix("192.168.1.100/24"),
+				netip.MustParsePrefix("10.0.0.5/8"),
+				netip.MustParsePrefix("2001:db8::5054:ff:fe12:3456/64"),
+				netip.MustParsePrefix("2001:db8:ffff::10/128"),
+				netip.MustParsePrefix("fe80::5054:ff:fe12:3456/64"),
+			},
+			Gateways: []netip.Addr{
+				netip.MustParseAddr("192.168.1.1"),
+				netip.MustParseAddr("fe80::1"),
+			},
+			DNS: []netip.Addr{
+				netip.MustParseAddr("192.168.1.1"),
+				netip.MustParseAddr("2001:db8::53"),
+				netip.MustParseAddr("2001:db8::1"),
+			},
+		}},
+	}
+
+	if diff := cmp.Diff(want, got, cmp.Comparer(addrEqual), cmp.Comparer(prefixEqual)); diff != "" {
+		t.Fatalf("unexpected status (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,547 @@
+package networkd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// WatchProperties subscribes to changes to the networkd Manager object's
+// properties. Each time networkd reports a change, the Manager's full set of
+// updated properties is sent on the returned channel. The channel is closed
+// and the subscription is ended when ctx is canceled.
+func (ms *ManagerService) WatchProperties(ctx context.Context) (<-chan ManagerProperties, error) {
+	_, ch, err := ms.watchProperties(ctx)
+	return ch, err
+}
+
+// watchProperties implements WatchProperties, also returning the Manager's
+// properties at the time the subscription began.
+func (ms *ManagerService) watchProperties(ctx context.Context) (ManagerProperties, <-chan ManagerProperties, error) {
+	props, ch, err := watchProperties(ctx, ms.c, objectPath(), interfacePath("Manager"), parseManagerProperties)
+	if err != nil {
+		return ManagerProperties{}, nil, fmt.Errorf("watch manager properties: %w", err)
+	}
+
+	return props, ch, nil
+}
+
+// WaitBelowState blocks until the system's operational state drops below
+// threshold, or until ctx is canceled. It is the inverse of WaitForState, and
+// is useful to sequence a graceful shutdown or to verify failover.
+func (ms *ManagerService) WaitBelowState(ctx context.Context, threshold OperationalState) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	props, ch, err := ms.watchProperties(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Stop watching and wait for the subscription to end.
+		cancel()
+		for range ch {
+		}
+	}()
+
+	err = waitProperties(ctx, props, ch, func(mp ManagerProperties) bool {
+		return !mp.OperationalState.AtLeast(threshold)
+	})
+	if err != nil {
+		return fmt.Errorf("wait for system to drop below state %q: %w", threshold, err)
+	}
+
+	return nil
+}
+
+// WatchProperties subscribes to changes to this link's properties. Each time
+// networkd reports a change, the link's full set of updated properties is sent
+// on the returned channel. The channel is closed and the subscription is ended
+// when ctx is canceled.
+func (ls *LinkService) WatchProperties(ctx context.Context) (<-chan LinkProperties, error) {
+	_, ch, err := ls.watchProperties(ctx)
+	return ch, err
+}
+
+// watchProperties implements WatchProperties, also returning the link's
+// properties at the time the subscription began.
+func (ls *LinkService) watchProperties(ctx context.Context) (LinkProperties, <-chan LinkProperties, error) {
+	props, ch, err := watchProperties(ctx, ls.c, ls.l.ObjectPath, interfacePath("Link"), parseLinkProperties)
+	if err != nil {
+		return LinkProperties{}, nil, fmt.Errorf("watch link %q properties: %w", ls.l.Name, err)
+	}
+
+	return props, ch, nil
+}
+
+// A LinkEvent indicates that a link was added to or removed from networkd.
+type LinkEvent struct {
+	Link    Link
+	Removed bool
+}
+
+// WatchLinks subscribes to the addition and removal of links managed by
+// networkd, such as a new veth pair or an unplugged USB NIC. Events are sent on
+// the returned channel until ctx is canceled, at which point the channel is
+// closed and the subscription is ended.
+//
+// networkd does not emit dedicated signals for added or removed links, so
+// WatchLinks lists the links again whenever a previously unknown link reports a
+// property change or a known link enters the linger state.
+func (ms *ManagerService) WatchLinks(ctx context.Context) (<-chan LinkEvent, error) {
+	_, events, err := ms.watchLinks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan LinkEvent)
+	go func() {
+		defer close(out)
+
+		for e := range events {
+			select {
+			case <-ctx.Done():
+				// Keep draining events until the subscription ends.
+			case out <- LinkEvent{Link: e.Link, Removed: e.Kind == EventLinkRemoved}:
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// A watchedLink is the last known state of a link tracked by watchLinks.
+type watchedLink struct {
+	l     Link
+	props Properties
+
+	// Whether the link's properties are tracked, and the raw properties to
+	// which changes are applied. raw is nil if the properties of a tracked
+	// link could not be fetched, in which case they are fetched again on the
+	// link's next change.
+	tracked bool
+	raw     map[string]dbus.Variant
+}
+
+// watchLinks implements WatchLinks using a single match rule for all link
+// objects, producing EventLinkAdded and EventLinkRemoved as links come and go.
+// The properties of links for which track returns true are also tracked, and
+// their changes are reported as EventLinkChanged. The links which existed at
+// the time the subscription began are also returned, ordered by index.
+func (ms *ManagerService) watchLinks(ctx context.Context, track func(Link) bool) ([]watchedLink, <-chan Event, error) {
+	if track == nil {
+		track = func(Link) bool { return false }
+	}
+
+	// A path_namespace match covers every link object, so the number of match
+	// rules does not grow with the number of links.
+	signals, cancel, err := ms.c.watch(ctx,
+		dbus.WithMatchPathNamespace(objectPath("link")),
+		dbus.WithMatchInterface(interfaceProperties),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, interfacePath("Link")),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("watch links: %w", err)
+	}
+
+	links, err := ms.ListLinks(ctx)
+	if err != nil {
+		_ = cancel()
+		return nil, nil, fmt.Errorf("watch links: %w", err)
+	}
+
+	known := make(map[dbus.ObjectPath]*watchedLink, len(links))
+	initial := make([]watchedLink, 0, len(links))
+	for _, l := range links {
+		wl := ms.watchedLink(ctx, l, track)
+		known[l.ObjectPath] = wl
+		initial = append(initial, *wl)
+	}
+	slices.SortFunc(initial, func(a, b watchedLink) int {
+		return a.l.Index - b.l.Index
+	})
+
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		send := func(events ...Event) bool {
+			for _, e := range events {
+				select {
+				case <-ctx.Done():
+					return false
+				case out <- e:
+				}
+			}
+
+			return true
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s, ok := <-signals:
+				if !ok {
+					// The D-Bus connection was closed.
+					return
+				}
+
+				changed, ok := propertiesChanged(s, s.Path, interfacePath("Link"))
+				if !ok || !strings.HasPrefix(string(s.Path), string(objectPath("link"))+"/") {
+					continue
+				}
+
+				wl, exists := known[s.Path]
+				lingering := false
+				if v, ok := changed["AdministrativeState"]; ok {
+					state, _ := v.Value().(string)
+					lingering = AdministrativeState(state) == AdministrativeLinger
+				}
+
+				if exists && !lingering {
+					if wl.tracked && wl.raw == nil {
+						// The initial fetch failed, so fetch the link's
+						// properties again rather than applying a partial
+						// change.
+						e, ok := ms.refetch(ctx, wl)
+						if ok && !send(e) {
+							return
+						}
+
+						continue
+					}
+
+					e, ok := wl.apply(changed)
+					if ok && !send(e) {
+						return
+					}
+
+					continue
+				}
+
+				links, err := ms.ListLinks(ctx)
+				if err != nil {
+					// Try again on the next signal.
+					continue
+				}
+
+				next := make(map[dbus.ObjectPath]*watchedLink, len(links))
+				for _, l := range links {
+					if lingering && l.ObjectPath == s.Path {
+						continue
+					}
+
+					if wl, ok := known[l.ObjectPath]; ok {
+						next[l.ObjectPath] = wl
+					} else {
+						next[l.ObjectPath] = ms.watchedLink(ctx, l, track)
+					}
+				}
+
+				if !send(diffLinks(known, next)...) {
+					return
+				}
+				known = next
+			}
+		}
+	}()
+
+	return initial, out, nil
+}
+
+// watchedLink produces a watchedLink for l, fetching its properties if track
+// returns true. Errors are ignored since the link may have been removed in the
+// meantime, in which case its removal will be reported shortly.
+func (ms *ManagerService) watchedLink(ctx context.Context, l Link, track func(Link) bool) *watchedLink {
+	wl := &watchedLink{l: l, tracked: track(l)}
+	if wl.tracked {
+		_ = ms.fetch(ctx, wl)
+	}
+
+	return wl
+}
+
+// fetch fetches and stores the properties of wl.
+func (ms *ManagerService) fetch(ctx context.Context, wl *watchedLink) error {
+	raw, err := ms.c.getAll(ctx, wl.l.ObjectPath, interfacePath("Link"))
+	if err != nil {
+		return err
+	}
+
+	props, err := parseLinkProperties(raw)
+	if err != nil {
+		return err
+	}
+
+	wl.props, wl.raw = props, raw
+	return nil
+}
+
+// refetch retries fetching the properties of a tracked link whose previous
+// fetch failed, producing an EventLinkChanged with nil Old properties if the
+// fetch succeeds.
+func (ms *ManagerService) refetch(ctx context.Context, wl *watchedLink) (Event, bool) {
+	if err := ms.fetch(ctx, wl); err != nil {
+		return Event{}, false
+	}
+
+	return Event{Kind: EventLinkChanged, Link: wl.l, New: wl.props}, true
+}
+
+// apply applies changed properties to wl, producing an EventLinkChanged if the
+// link's properties are known and the result could be parsed.
+func (wl *watchedLink) apply(changed map[string]dbus.Variant) (Event, bool) {
+	if wl.raw == nil {
+		return Event{}, false
+	}
+
+	next := make(map[string]dbus.Variant, len(wl.raw))
+	for k, v := range wl.raw {
+		next[k] = v
+	}
+	for k, v := range changed {
+		next[k] = v
+	}
+
+	props, err := parseLinkProperties(next)
+	if err != nil {
+		// Keep the last known good properties.
+		return Event{}, false
+	}
+
+	e := Event{Kind: EventLinkChanged, Link: wl.l, Old: wl.props, New: props}
+	wl.props, wl.raw = props, next
+	return e, true
+}
+
+// diffLinks produces Events for the links removed from prev and added in next.
+func diffLinks(prev, next map[dbus.ObjectPath]*watchedLink) []Event {
+	var events []Event
+	for op, wl := range prev {
+		if _, ok := next[op]; !ok {
+			events = append(events, Event{Kind: EventLinkRemoved, Link: wl.l, Old: wl.props})
+		}
+	}
+	for op, wl := range next {
+		if _, ok := prev[op]; !ok {
+			events = append(events, Event{Kind: EventLinkAdded, Link: wl.l, New: wl.props})
+		}
+	}
+
+	// Report removals before additions so that a link whose index is reused
+	// is removed before its replacement is added, and otherwise order by
+	// index.
+	slices.SortFunc(events, func(a, b Event) int {
+		switch {
+		case a.Kind == EventLinkRemoved && b.Kind != EventLinkRemoved:
+			return -1
+		case a.Kind != EventLinkRemoved && b.Kind == EventLinkRemoved:
+			return 1
+		default:
+			return a.Link.Index - b.Link.Index
+		}
+	})
+
+	return events
+}
+
+// watchProperties subscribes to PropertiesChanged signals for iface on op and
+// sends the object's properties, parsed by parse, on the returned channel each
+// time they change. The object's properties at the time the subscription began
+// are also returned.
+func watchProperties[T any](
+	ctx context.Context,
+	c *Client,
+	op dbus.ObjectPath,
+	iface string,
+	parse func(map[string]dbus.Variant) (T, error),
+) (T, <-chan T, error) {
+	var zero T
+
+	// Subscribe before fetching the current properties so that no changes
+	// are missed in between.
+	signals, cancel, err := c.watch(ctx,
+		dbus.WithMatchObjectPath(op),
+		dbus.WithMatchInterface(interfaceProperties),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, iface),
+	)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	// PropertiesChanged only carries the properties which changed, so keep
+	// a full copy of the properties to apply each change to.
+	props, err := c.getAll(ctx, op, iface)
+	if err != nil {
+		_ = cancel()
+		return zero, nil, err
+	}
+
+	initial, err := parse(props)
+	if err != nil {
+		_ = cancel()
+		return zero, nil, err
+	}
+
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s, ok := <-signals:
+				if !ok {
+					// The D-Bus connection was closed.
+					return
+				}
+
+				changed, ok := propertiesChanged(s, op, iface)
+				if !ok {
+					continue
+				}
+
+				next := make(map[string]dbus.Variant, len(props))
+				for k, v := range props {
+					next[k] = v
+				}
+				for k, v := range changed {
+					next[k] = v
+				}
+
+				v, err := parse(next)
+				if err != nil {
+					// Skip any update which could not be parsed, keeping
+					// the last known good properties.
+					continue
+				}
+				props = next
+
+				select {
+				case <-ctx.Done():
+					return
+				case out <- v:
+				}
+			}
+		}
+	}()
+
+	return initial, out, nil
+}
+
+// waitProperties blocks until done reports true for either the initial
+// properties or the properties sent on ch, or until ctx is canceled. ch must be
+// produced by watchProperties using ctx.
+func waitProperties[T any](ctx context.Context, initial T, ch <-chan T, done func(T) bool) error {
+	for v, ok := initial, true; ; {
+		if !ok {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			return errConnClosed
+		}
+		if done(v) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case v, ok = <-ch:
+		}
+	}
+}
+
+// Running reports whether networkd is currently connected to the bus.
+func (c *Client) Running(ctx context.Context) (bool, error) {
+	var ok bool
+	if err := c.call(ctx, busService, busService+".NameHasOwner", busObject, &ok, baseService); err != nil {
+		return false, fmt.Errorf("check networkd name owner: %w", err)
+	}
+
+	return ok, nil
+}
+
+// WatchRunning subscribes to networkd starting and stopping, which allows
+// callers to distinguish a networkd instance with no links from one which is
+// not running at all. true is sent on the returned channel when networkd
+// appears on the bus, and false when it disappears. The channel is closed and
+// the subscription is ended when ctx is canceled.
+func (c *Client) WatchRunning(ctx context.Context) (<-chan bool, error) {
+	owners, err := c.watchNameOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan bool)
+	go func() {
+		defer close(out)
+
+		for owner := range owners {
+			select {
+			case <-ctx.Done():
+				// Keep draining owners until the subscription ends.
+			case out <- owner != "":
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// watchNameOwner subscribes to changes in ownership of the networkd D-Bus
+// service name. The new owner's unique name is sent on the returned channel
+// each time networkd starts, and an empty string when it stops.
+func (c *Client) watchNameOwner(ctx context.Context) (<-chan string, error) {
+	signals, cancel, err := c.watch(ctx,
+		dbus.WithMatchSender(busService),
+		dbus.WithMatchObjectPath(busObject),
+		dbus.WithMatchInterface(busService),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, baseService),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("watch networkd name owner: %w", err)
+	}
+
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s, ok := <-signals:
+				if !ok {
+					// The D-Bus connection was closed.
+					return
+				}
+
+				owner, ok := nameOwnerChanged(s, baseService)
+				if !ok {
+					continue
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case out <- owner:
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,492 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestManagerServiceWatchProperties(t *testing.T) {
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath(): testManagerProperties(),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	props, err := c.Manager.WatchProperties(ctx)
+	if err != nil {
+		t.Fatalf("failed to watch properties: %v", err)
+	}
+
+	// Signals for other objects and interfaces are ignored.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded")
+	signals <- testPropertiesChanged(objectPath(), "Link", "OperationalState", "degraded")
+	signals <- testPropertiesChanged(objectPath(), "Manager", "OperationalState", "degraded")
+	signals <- testPropertiesChanged(objectPath(), "Manager", "OnlineState", "partial")
+
+	want := []ManagerProperties{
+		{
+			OperationalState: OperationalDegraded,
+			CarrierState:     CarrierCarrier,
+			AddressState:     AddressRoutable,
+			IPv4AddressState: AddressRoutable,
+			IPv6AddressState: AddressDegraded,
+			OnlineState:      OnlineOnline,
+		},
+		{
+			OperationalState: OperationalDegraded,
+			CarrierState:     CarrierCarrier,
+			AddressState:     AddressRoutable,
+			IPv4AddressState: AddressRoutable,
+			IPv6AddressState: AddressDegraded,
+			OnlineState:      OnlinePartial,
+		},
+	}
+
+	got := []ManagerProperties{<-props, <-props}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(ManagerProperties{}, "Raw")); diff != "" {
+		t.Fatalf("unexpected properties (-want +got):\n%s", diff)
+	}
+
+	cancel()
+	for range props {
+	}
+
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestLinkServiceWatchProperties(t *testing.T) {
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLink.ObjectPath: testLinkProperties(),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	props, err := c.Link(testLink).WatchProperties(ctx)
+	if err != nil {
+		t.Fatalf("failed to watch properties: %v", err)
+	}
+
+	// Signals for other links and malformed updates are ignored.
+	signals <- testPropertiesChanged(objectPath("link", "_33"), "Link", "OperationalState", "degraded")
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "BitRates", []any{uint64(1)})
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "CarrierState", "no-carrier")
+
+	want := LinkProperties{
+		OperationalState:    OperationalRoutable,
+		CarrierState:        CarrierNoCarrier,
+		AddressState:        AddressRoutable,
+		IPv4AddressState:    AddressRoutable,
+		IPv6AddressState:    AddressDegraded,
+		OnlineState:         OnlineOnline,
+		AdministrativeState: AdministrativeConfigured,
+		TxBitRate:           1000000,
+		RxBitRate:           2000000,
+	}
+
+	if diff := cmp.Diff(want, <-props, cmpopts.IgnoreFields(LinkProperties{}, "Raw")); diff != "" {
+		t.Fatalf("unexpected properties (-want +got):\n%s", diff)
+	}
+
+	cancel()
+	for range props {
+	}
+
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestManagerServiceWaitBelowState(t *testing.T) {
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath(): testManagerProperties(),
+	})
+
+	errC := make(chan error)
+	go func() { errC <- c.Manager.WaitBelowState(context.Background(), OperationalDegraded) }()
+
+	testWaitWatches(t, active, 1)
+
+	// degraded does not drop below the threshold.
+	signals <- testPropertiesChanged(objectPath(), "Manager", "OperationalState", "degraded")
+
+	select {
+	case err := <-errC:
+		t.Fatalf("wait returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	signals <- testPropertiesChanged(objectPath(), "Manager", "OperationalState", "no-carrier")
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to wait below state: %v", err)
+	}
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestLinkServiceWaitBelowState(t *testing.T) {
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLink.ObjectPath: testLinkProperties(),
+	})
+
+	errC := make(chan error)
+	go func() { errC <- c.Link(testLink).WaitBelowState(context.Background(), OperationalCarrier) }()
+
+	testWaitWatches(t, active, 1)
+
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "carrier")
+
+	select {
+	case err := <-errC:
+		t.Fatalf("wait returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A lingering link is being removed, regardless of its operational state.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "AdministrativeState", "linger")
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to wait below state: %v", err)
+	}
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.Link(testLink).WaitBelowState(ctx, OperationalCarrier); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, but got: %v", err)
+	}
+}
+
+func TestManagerServiceWatchLinks(t *testing.T) {
+	eth1 := Link{Index: 3, Name: "eth1", ObjectPath: objectPath("link", "_33")}
+
+	c, signals, active := testWatchClient(t, nil)
+	setLinks := testDynamicLinks(t, c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := c.Manager.WatchLinks(ctx)
+	if err != nil {
+		t.Fatalf("failed to watch links: %v", err)
+	}
+
+	// Changes to known links do not produce events, but an unknown link is
+	// reported as added.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded")
+	setLinks(testLinks[0], testLink, eth1)
+	signals <- testPropertiesChanged(eth1.ObjectPath, "Link", "AdministrativeState", "pending")
+
+	if diff := cmp.Diff(LinkEvent{Link: eth1}, <-events); diff != "" {
+		t.Fatalf("unexpected added event (-want +got):\n%s", diff)
+	}
+
+	// A lingering link is reported as removed.
+	setLinks(testLinks[0], eth1)
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "AdministrativeState", "linger")
+
+	if diff := cmp.Diff(LinkEvent{Link: testLink, Removed: true}, <-events); diff != "" {
+		t.Fatalf("unexpected removed event (-want +got):\n%s", diff)
+	}
+
+	cancel()
+	for range events {
+	}
+
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestManagerServiceWatchLinksRefetch(t *testing.T) {
+	c, signals, _ := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	// The first fetch of eth0's properties fails.
+	var (
+		getAll = c.getAll
+		failed atomic.Bool
+	)
+	c.getAll = func(ctx context.Context, op dbus.ObjectPath, iface string) (map[string]dbus.Variant, error) {
+		if op == testLink.ObjectPath && failed.CompareAndSwap(false, true) {
+			return nil, errors.New("timed out")
+		}
+
+		return getAll(ctx, op, iface)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	initial, events, err := c.Manager.watchLinks(ctx, func(Link) bool { return true })
+	if err != nil {
+		t.Fatalf("failed to watch links: %v", err)
+	}
+	if initial[1].props != nil {
+		t.Fatalf("expected no initial properties for eth0, but got: %v", initial[1].props)
+	}
+
+	// The next change fetches all of the link's properties.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded")
+
+	e := <-events
+	if diff := cmp.Diff(EventLinkChanged, e.Kind); diff != "" {
+		t.Fatalf("unexpected event kind (-want +got):\n%s", diff)
+	}
+	if e.Old != nil {
+		t.Fatalf("expected nil old properties, but got: %v", e.Old)
+	}
+
+	props, ok := e.New.(LinkProperties)
+	if !ok {
+		t.Fatalf("unexpected new properties: %v", e.New)
+	}
+	if diff := cmp.Diff(OperationalRoutable, props.OperationalState); diff != "" {
+		t.Fatalf("unexpected operational state (-want +got):\n%s", diff)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestClientRunning(t *testing.T) {
+	c := &Client{
+		call: func(_ context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
+			if diff := cmp.Diff(busService, service); diff != "" {
+				t.Fatalf("unexpected service (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff("org.freedesktop.DBus.NameHasOwner", method); diff != "" {
+				t.Fatalf("unexpected method (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(busObject, op); diff != "" {
+				t.Fatalf("unexpected object path (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff([]any{baseService}, args); diff != "" {
+				t.Fatalf("unexpected arguments (-want +got):\n%s", diff)
+			}
+
+			*out.(*bool) = true
+			return nil
+		},
+	}
+
+	ok, err := c.Running(context.Background())
+	if err != nil {
+		t.Fatalf("failed to check running: %v", err)
+	}
+	if !ok {
+		t.Fatal("networkd is not running")
+	}
+}
+
+func TestClientWatchRunning(t *testing.T) {
+	c, signals, active := testWatchClient(t, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	running, err := c.WatchRunning(ctx)
+	if err != nil {
+		t.Fatalf("failed to watch running: %v", err)
+	}
+
+	signals <- testNameOwnerChanged("org.freedesktop.resolve1", "")
+	signals <- testNameOwnerChanged(baseService, "")
+	signals <- testNameOwnerChanged(baseService, ":1.42")
+
+	if diff := cmp.Diff([]bool{false, true}, []bool{<-running, <-running}); diff != "" {
+		t.Fatalf("unexpected running states (-want +got):\n%s", diff)
+	}
+
+	cancel()
+	for range running {
+	}
+
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestWatchConnectionClosed(t *testing.T) {
+	var canceled atomic.Int32
+	c := &Client{
+		get: func(_ context.Context, _ dbus.ObjectPath, _, _ string) (dbus.Variant, error) {
+			return dbus.MakeVariant("no-carrier"), nil
+		},
+		getAll: func(_ context.Context, op dbus.ObjectPath, _ string) (map[string]dbus.Variant, error) {
+			if op == objectPath() {
+				return testManagerProperties(), nil
+			}
+
+			return testLinkProperties(), nil
+		},
+		// The signal channel is closed when the D-Bus connection is closed.
+		watch: func(_ context.Context, _ ...dbus.MatchOption) (<-chan *dbus.Signal, func() error, error) {
+			ch := make(chan *dbus.Signal)
+			close(ch)
+
+			return ch, func() error {
+				canceled.Add(1)
+				return nil
+			}, nil
+		},
+	}
+	c.Manager = &ManagerService{c: c}
+	_ = testDynamicLinks(t, c)
+
+	props, err := c.Link(testLink).WatchProperties(context.Background())
+	if err != nil {
+		t.Fatalf("failed to watch properties: %v", err)
+	}
+	for range props {
+	}
+
+	if err := c.Link(testLink).WaitForState(context.Background(), OperationalRoutable); !errors.Is(err, errConnClosed) {
+		t.Fatalf("expected connection closed error, but got: %v", err)
+	}
+
+	w, err := c.Watch(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	for range w.Events() {
+	}
+
+	if err := w.Err(); !errors.Is(err, errConnClosed) {
+		t.Fatalf("expected connection closed error, but got: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close watcher: %v", err)
+	}
+
+	// Property and state watches for the link, plus the Watcher's name owner,
+	// Manager, and links watches.
+	if diff := cmp.Diff(int32(5), canceled.Load()); diff != "" {
+		t.Fatalf("unexpected number of canceled watches (-want +got):\n%s", diff)
+	}
+}
+
+// testWatchClient produces a Client which serves properties for the objects
+// in objects and broadcasts the signals sent on the returned channel to all
+// watchers. The returned atomic.Int32 counts the watches which have not been
+// canceled.
+func testWatchClient(t *testing.T, objects map[dbus.ObjectPath]map[string]dbus.Variant) (*Client, chan<- *dbus.Signal, *atomic.Int32) {
+	t.Helper()
+
+	var (
+		signals = make(chan *dbus.Signal)
+		active  atomic.Int32
+
+		mu   sync.Mutex
+		subs = make(map[chan *dbus.Signal]struct{})
+	)
+
+	go func() {
+		for s := range signals {
+			mu.Lock()
+			for ch := range subs {
+				select {
+				case ch <- s:
+				default:
+					t.Error("dropped signal for full watcher channel")
+				}
+			}
+			mu.Unlock()
+		}
+	}()
+	t.Cleanup(func() { close(signals) })
+
+	c := &Client{
+		getAll: func(_ context.Context, op dbus.ObjectPath, _ string) (map[string]dbus.Variant, error) {
+			props, ok := objects[op]
+			if !ok {
+				return nil, dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownObject"}
+			}
+
+			return props, nil
+		},
+		watch: func(_ context.Context, _ ...dbus.MatchOption) (<-chan *dbus.Signal, func() error, error) {
+			ch := make(chan *dbus.Signal, 16)
+
+			mu.Lock()
+			defer mu.Unlock()
+			subs[ch] = struct{}{}
+			active.Add(1)
+
+			return ch, func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				delete(subs, ch)
+				active.Add(-1)
+				return nil
+			}, nil
+		},
+	}
+	c.Manager = &ManagerService{c: c}
+
+	return c, signals, &active
+}
+
+// testDynamicLinks configures c to serve ListLinks from a set of links which
+// is initialized to testLinks and replaced by calling the returned function.
+func testDynamicLinks(t *testing.T, c *Client) func(links ...Link) {
+	t.Helper()
+
+	var (
+		mu    sync.Mutex
+		links = testLinks
+	)
+
+	c.call = testManagerClient(t, func(method string, _ []any) []any {
+		if diff := cmp.Diff("ListLinks", method); diff != "" {
+			t.Errorf("unexpected method (-want +got):\n%s", diff)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		vs := make([][]any, 0, len(links))
+		for _, l := range links {
+			vs = append(vs, []any{int32(l.Index), l.Name, l.ObjectPath})
+		}
+
+		return []any{vs}
+	}).call
+
+	return func(ls ...Link) {
+		mu.Lock()
+		defer mu.Unlock()
+		links = ls
+	}
+}
+
+// testNameOwnerChanged produces a NameOwnerChanged signal indicating that name
+// is now owned by owner.
+func testNameOwnerChanged(name, owner string) *dbus.Signal {
+	return &dbus.Signal{
+		Sender: busService,
+		Path:   busObject,
+		Name:   signalNameOwnerChanged,
+		Body:   []any{name, ":1.1", owner},
+	}
+}
@@ -0,0 +1,742 @@
+package networkd
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"path"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// An EventKind indicates the type of change reported by an Event.
+type EventKind int
+
+// Possible EventKind values. EventCarrierGained and EventCarrierLost are
+// reported immediately after the EventLinkChanged which caused them.
+// EventIPv4Routable and EventIPv6Routable are reported after the
+// EventLinkChanged which caused them once the link's addresses are fetched, so
+// other events may be reported in between.
+const (
+	_ EventKind = iota
+	EventManagerChanged
+	EventLinkAdded
+	EventLinkRemoved
+	EventLinkChanged
+	EventResync
+	EventSnapshotDone
+	EventCarrierGained
+	EventCarrierLost
+	EventIPv4Routable
+	EventIPv6Routable
+)
+
+// String returns the string representation of an EventKind.
+func (k EventKind) String() string {
+	switch k {
+	case EventManagerChanged:
+		return "manager changed"
+	case EventLinkAdded:
+		return "link added"
+	case EventLinkRemoved:
+		return "link removed"
+	case EventLinkChanged:
+		return "link changed"
+	case EventResync:
+		return "resync"
+	case EventSnapshotDone:
+		return "snapshot done"
+	case EventCarrierGained:
+		return "carrier gained"
+	case EventCarrierLost:
+		return "carrier lost"
+	case EventIPv4Routable:
+		return "IPv4 routable"
+	case EventIPv6Routable:
+		return "IPv6 routable"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// Properties is implemented by ManagerProperties and LinkProperties.
+type Properties interface {
+	isProperties()
+}
+
+func (ManagerProperties) isProperties() {}
+func (LinkProperties) isProperties()    {}
+
+// An Event is a change to the state of networkd reported by a Watcher.
+type Event struct {
+	// Kind indicates the type of change.
+	Kind EventKind
+
+	// Link is the link which changed, or the zero value for Manager events.
+	Link Link
+
+	// Old and New are the ManagerProperties or LinkProperties before and
+	// after the change. Old is nil for added links and New is nil for removed
+	// links. Both are nil for links whose properties could not be fetched,
+	// and Old is nil when they are fetched again on the link's next change.
+	Old, New Properties
+
+	// Addresses contains the link's routable addresses in the relevant family
+	// for EventIPv4Routable and EventIPv6Routable.
+	Addresses []netip.Prefix
+}
+
+// WatchOptions configures a Watcher.
+type WatchOptions struct {
+	// Debounce, if non-zero, delays reporting Manager and link property
+	// changes until no further changes have occurred on the same object for
+	// the duration. Changes which settle back to the original properties,
+	// such as a link flapping from routable to degraded and back, are not
+	// reported at all.
+	Debounce time.Duration
+
+	// Coalesce, if non-zero, merges all Manager and link property changes on
+	// the same object within the duration of the first change into a single
+	// consolidated event, protecting slow consumers from bursts such as those
+	// produced when a link is reconfigured. Unlike Debounce, a change is
+	// never delayed for longer than Coalesce. If both are set, Debounce
+	// applies but a change is reported no later than Coalesce after it
+	// occurred.
+	Coalesce time.Duration
+
+	// Links, if set, limits link events to links whose names match at least
+	// one of the glob patterns, as used by path.Match. Links which do not
+	// match are not watched at all.
+	Links []string
+
+	// MinOperationalState, if set, limits Manager and link change events to
+	// those where the operational state before or after the change is at
+	// least the minimum.
+	MinOperationalState OperationalState
+
+	// Snapshot, if set, reports the current state before any changes: an
+	// EventManagerChanged with nil Old properties, an EventLinkAdded for each
+	// existing link, and finally EventSnapshotDone. The snapshot is reported
+	// again after each EventResync. Because the snapshot is taken after the
+	// Watcher subscribes, no changes are missed between the two.
+	Snapshot bool
+
+	// Workers sets the number of goroutines used by WatchFunc to invoke its
+	// callback. If zero, one goroutine is used.
+	Workers int
+}
+
+// matchLink reports whether l's name matches the Links patterns.
+func (o *WatchOptions) matchLink(l Link) bool {
+	if len(o.Links) == 0 {
+		return true
+	}
+
+	for _, p := range o.Links {
+		if ok, _ := path.Match(p, l.Name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match reports whether e satisfies the filters set by o.
+func (o *WatchOptions) match(e Event) bool {
+	switch e.Kind {
+	case EventLinkAdded, EventLinkRemoved, EventLinkChanged,
+		EventCarrierGained, EventCarrierLost, EventIPv4Routable, EventIPv6Routable:
+		if !o.matchLink(e.Link) {
+			return false
+		}
+	}
+
+	if o.MinOperationalState == "" || (e.Kind != EventManagerChanged && e.Kind != EventLinkChanged) {
+		return true
+	}
+
+	atLeast := func(p Properties) bool {
+		switch p := p.(type) {
+		case ManagerProperties:
+			return p.OperationalState.AtLeast(o.MinOperationalState)
+		case LinkProperties:
+			return p.OperationalState.AtLeast(o.MinOperationalState)
+		default:
+			return false
+		}
+	}
+
+	return atLeast(e.Old) || atLeast(e.New)
+}
+
+// A Watcher multiplexes changes to the networkd Manager and its links into a
+// single stream of Events.
+type Watcher struct {
+	events chan Event
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	err    error
+}
+
+// Watch creates a Watcher which reports Manager property changes, link
+// property changes, and links being added or removed. The Watcher runs until
+// ctx is canceled or Close is called. If opts is nil, defaults are used.
+//
+// If networkd restarts, the Watcher subscribes to the new instance and reports
+// an EventResync, after which any previously reported links may be stale.
+func (c *Client) Watch(ctx context.Context, opts *WatchOptions) (*Watcher, error) {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+	for _, p := range opts.Links {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid link name pattern %q: %w", p, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Watch for networkd restarts before anything else so that a restart
+	// cannot be missed while the initial subscriptions are created.
+	owners, err := c.watchNameOwner(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	w := &Watcher{
+		events: make(chan Event),
+		cancel: cancel,
+	}
+
+	ws := &watchState{
+		ctx:      ctx,
+		c:        c,
+		opts:     *opts,
+		wg:       &w.wg,
+		pending:  make(map[dbus.ObjectPath]*pendingEvent),
+		fired:    make(chan firedEvent),
+		routable: make(chan Event),
+	}
+
+	if err := ws.start(); err != nil {
+		cancel()
+		drain(&w.wg, owners)
+		w.wg.Wait()
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer close(w.events)
+
+		w.err = ws.run(w.events, owners)
+		cancel()
+		ws.stop()
+		drain(&w.wg, owners)
+	}()
+
+	return w, nil
+}
+
+// WatchFunc is like Watch, but instead of delivering Events on a channel, it
+// invokes fn for each Event on a pool of goroutines sized by opts.Workers.
+// Events for the same link are always handled in order by the same goroutine,
+// while events for different links may be handled concurrently. Events which
+// do not concern a link are handled by the same goroutine as one another.
+//
+// WatchFunc blocks until ctx is canceled or the Watcher stops due to an error,
+// and returns only after all invocations of fn have returned.
+func (c *Client) WatchFunc(ctx context.Context, opts *WatchOptions, fn func(Event)) error {
+	w, err := c.Watch(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	workers := 1
+	if opts != nil && opts.Workers > 0 {
+		workers = opts.Workers
+	}
+
+	var (
+		wg     sync.WaitGroup
+		queues = make([]chan Event, workers)
+	)
+
+	for i := range queues {
+		queues[i] = make(chan Event)
+
+		wg.Add(1)
+		go func(q <-chan Event) {
+			defer wg.Done()
+			for e := range q {
+				fn(e)
+			}
+		}(queues[i])
+	}
+
+	for e := range w.Events() {
+		queues[e.Link.Index%workers] <- e
+	}
+
+	for _, q := range queues {
+		close(q)
+	}
+	wg.Wait()
+
+	if err := w.Err(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// Events returns a channel of Events which is closed when the Watcher stops.
+func (w *Watcher) Events() <-chan Event { return w.events }
+
+// Err returns the error which caused the Watcher to stop, if any. Err must
+// only be called after the Events channel is closed.
+func (w *Watcher) Err() error { return w.err }
+
+// Close stops the Watcher and waits for its subscriptions to end.
+func (w *Watcher) Close() error {
+	w.cancel()
+	w.wg.Wait()
+	return nil
+}
+
+// watchState is the internal state of a Watcher.
+type watchState struct {
+	ctx  context.Context
+	c    *Client
+	opts WatchOptions
+	wg   *sync.WaitGroup
+	sess *watchSession
+
+	// Events awaiting the end of their debounce or coalescing period.
+	pending map[dbus.ObjectPath]*pendingEvent
+	fired   chan firedEvent
+
+	// Routable events whose addresses were fetched in the background.
+	routable chan Event
+}
+
+// A watchSession holds the subscriptions for a single instance of networkd.
+type watchSession struct {
+	cancel   context.CancelFunc
+	manager  ManagerProperties
+	managerC <-chan ManagerProperties
+	linksC   <-chan Event
+
+	// The last known state of each link, for snapshots.
+	links map[dbus.ObjectPath]watchedLink
+}
+
+// A pendingEvent is a debounced or coalesced Event which has not yet been reported.
+type pendingEvent struct {
+	e     Event
+	first time.Time
+	gen   uint64
+	timer *time.Timer
+}
+
+// A firedEvent indicates that the delay for an object's pending Event has
+// ended.
+type firedEvent struct {
+	op  dbus.ObjectPath
+	gen uint64
+}
+
+// start begins a new session of subscriptions to networkd.
+func (ws *watchState) start() error {
+	ctx, cancel := context.WithCancel(ws.ctx)
+
+	manager, managerC, err := ws.c.Manager.watchProperties(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	links, linksC, err := ws.c.Manager.watchLinks(ctx, ws.opts.matchLink)
+	if err != nil {
+		cancel()
+		drain(ws.wg, managerC)
+		return err
+	}
+
+	ws.sess = &watchSession{
+		cancel:   cancel,
+		manager:  manager,
+		managerC: managerC,
+		linksC:   linksC,
+		links:    make(map[dbus.ObjectPath]watchedLink, len(links)),
+	}
+
+	for _, wl := range links {
+		ws.sess.links[wl.l.ObjectPath] = wl
+	}
+
+	return nil
+}
+
+// stop ends the current session, if any, discarding its pending events.
+func (ws *watchState) stop() {
+	if ws.sess == nil {
+		return
+	}
+
+	ws.sess.cancel()
+	for _, pe := range ws.pending {
+		ws.stopTimer(pe)
+	}
+	clear(ws.pending)
+
+	// Wait for the subscriptions to end in the background so their match
+	// rules are removed before Close returns.
+	drain(ws.wg, ws.sess.managerC)
+	drain(ws.wg, ws.sess.linksC)
+	ws.sess = nil
+}
+
+// drain consumes ch in the background until it is closed.
+func drain[T any](wg *sync.WaitGroup, ch <-chan T) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range ch {
+		}
+	}()
+}
+
+// run processes changes until the Watcher's context is canceled, sending
+// Events on out.
+func (ws *watchState) run(out chan<- Event, owners <-chan string) error {
+	send := func(events ...Event) bool {
+		for _, e := range events {
+			if !ws.opts.match(e) {
+				continue
+			}
+
+			select {
+			case <-ws.ctx.Done():
+				return false
+			case out <- e:
+			}
+		}
+
+		return true
+	}
+
+	if ws.opts.Snapshot && !send(ws.snapshot()...) {
+		return nil
+	}
+
+	for {
+		// The session's channels are nil while networkd is not running,
+		// and nil channels are never ready.
+		var (
+			managerC <-chan ManagerProperties
+			linksC   <-chan Event
+		)
+		if ws.sess != nil {
+			managerC, linksC = ws.sess.managerC, ws.sess.linksC
+		}
+
+		var e Event
+		select {
+		case <-ws.ctx.Done():
+			return nil
+		case owner, ok := <-owners:
+			if !ok {
+				return ws.closed()
+			}
+
+			// networkd stopped or restarted, so the existing objects and
+			// their subscriptions are no longer valid.
+			ws.stop()
+			if owner == "" {
+				continue
+			}
+
+			if err := ws.start(); err != nil {
+				return fmt.Errorf("resubscribe after networkd restart: %w", err)
+			}
+
+			if !send(Event{Kind: EventResync, New: ws.sess.manager}) {
+				return nil
+			}
+			if ws.opts.Snapshot && !send(ws.snapshot()...) {
+				return nil
+			}
+			continue
+		case props, ok := <-managerC:
+			if !ok {
+				return ws.closed()
+			}
+
+			e = Event{Kind: EventManagerChanged, Old: ws.sess.manager, New: props}
+			ws.sess.manager = props
+
+			if ws.opts.delayed() {
+				ws.delay(objectPath(), e)
+				continue
+			}
+		case le, ok := <-linksC:
+			if !ok {
+				return ws.closed()
+			}
+
+			e = le
+			op := e.Link.ObjectPath
+			switch e.Kind {
+			case EventLinkAdded:
+				ws.sess.links[op] = watchedLink{l: e.Link, props: e.New}
+			case EventLinkRemoved:
+				delete(ws.sess.links, op)
+				if pe, ok := ws.pending[op]; ok {
+					ws.stopTimer(pe)
+					delete(ws.pending, op)
+				}
+			case EventLinkChanged:
+				ws.sess.links[op] = watchedLink{l: e.Link, props: e.New}
+
+				if ws.opts.delayed() {
+					ws.delay(op, e)
+					continue
+				}
+			}
+		case f := <-ws.fired:
+			pe, ok := ws.pending[f.op]
+			if !ok || pe.gen != f.gen {
+				// Superseded by a later change.
+				continue
+			}
+
+			delete(ws.pending, f.op)
+			if propertiesEqual(pe.e.Old, pe.e.New) {
+				continue
+			}
+
+			e = pe.e
+		case re := <-ws.routable:
+			// Drop events for links which disappeared, or which belong to a
+			// previous instance of networkd, while fetching addresses.
+			if ws.sess == nil {
+				continue
+			}
+			if wl, ok := ws.sess.links[re.Link.ObjectPath]; !ok || wl.l != re.Link {
+				continue
+			}
+
+			e = re
+		}
+
+		if !send(append([]Event{e}, ws.derivedEvents(e)...)...) {
+			return nil
+		}
+	}
+}
+
+// derivedEvents produces any additional Events implied by a link change, such
+// as a cable being plugged in or unplugged. Routable events require a D-Bus
+// request and are produced in the background by fetchRoutable.
+func (ws *watchState) derivedEvents(e Event) []Event {
+	if e.Kind != EventLinkChanged {
+		return nil
+	}
+
+	oldp, ok := e.Old.(LinkProperties)
+	if !ok {
+		return nil
+	}
+	newp, ok := e.New.(LinkProperties)
+	if !ok {
+		return nil
+	}
+
+	// Any carrier, including a degraded carrier on a bond or bridge, counts.
+	var events []Event
+	switch was, is := oldp.CarrierState.AtLeast(CarrierDegradedCarrier), newp.CarrierState.AtLeast(CarrierDegradedCarrier); {
+	case !was && is:
+		events = append(events, Event{Kind: EventCarrierGained, Link: e.Link, Old: e.Old, New: e.New})
+	case was && !is:
+		events = append(events, Event{Kind: EventCarrierLost, Link: e.Link, Old: e.Old, New: e.New})
+	}
+
+	for _, f := range []struct {
+		kind     EventKind
+		family   int
+		old, new AddressState
+	}{
+		{EventIPv4Routable, afINET, oldp.IPv4AddressState, newp.IPv4AddressState},
+		{EventIPv6Routable, afINET6, oldp.IPv6AddressState, newp.IPv6AddressState},
+	} {
+		if f.old == AddressRoutable || f.new != AddressRoutable {
+			continue
+		}
+
+		ws.fetchRoutable(Event{
+			Kind: f.kind,
+			Link: e.Link,
+			Old:  e.Old,
+			New:  e.New,
+		}, f.family)
+	}
+
+	return events
+}
+
+// fetchRoutable fetches the routable addresses of family for e's link in the
+// background so that a slow networkd does not block the run loop, and then
+// delivers e to the run loop.
+func (ws *watchState) fetchRoutable(e Event, family int) {
+	ws.wg.Add(1)
+	go func() {
+		defer ws.wg.Done()
+
+		e.Addresses = ws.routableAddresses(e.Link, family)
+		select {
+		case <-ws.ctx.Done():
+		case ws.routable <- e:
+		}
+	}()
+}
+
+// routableAddresses fetches the routable addresses of family for l. Errors are
+// ignored since the link may have been removed in the meantime.
+func (ws *watchState) routableAddresses(l Link, family int) []netip.Prefix {
+	b, err := ws.c.Link(l).DescribeRaw(ws.ctx)
+	if err != nil {
+		return nil
+	}
+
+	jd, err := decodeLinkDescription(b)
+	if err != nil {
+		return nil
+	}
+
+	var addrs []netip.Prefix
+	for _, a := range jd.Addresses {
+		addr := netip.Addr(a.Address)
+		if a.Family != family || !addr.IsGlobalUnicast() {
+			continue
+		}
+
+		addrs = append(addrs, netip.PrefixFrom(addr, a.PrefixLength))
+	}
+
+	return addrs
+}
+
+// snapshot produces Events describing the current session's state: the
+// Manager's properties, each link ordered by index, and EventSnapshotDone.
+func (ws *watchState) snapshot() []Event {
+	events := []Event{{Kind: EventManagerChanged, New: ws.sess.manager}}
+
+	links := make([]watchedLink, 0, len(ws.sess.links))
+	for _, wl := range ws.sess.links {
+		links = append(links, wl)
+	}
+	slices.SortFunc(links, func(a, b watchedLink) int {
+		return a.l.Index - b.l.Index
+	})
+
+	for _, wl := range links {
+		events = append(events, Event{Kind: EventLinkAdded, Link: wl.l, New: wl.props})
+	}
+
+	return append(events, Event{Kind: EventSnapshotDone})
+}
+
+// closed returns the error which caused a subscription to end: nil if the
+// Watcher's context was canceled, or an error indicating that the D-Bus
+// connection was closed.
+func (ws *watchState) closed() error {
+	if ws.ctx.Err() != nil {
+		return nil
+	}
+
+	return errConnClosed
+}
+
+// delayed reports whether property changes are debounced or coalesced.
+func (o *WatchOptions) delayed() bool { return o.Debounce > 0 || o.Coalesce > 0 }
+
+// delay holds e for op until its debounce or coalescing period ends, merging
+// it with any earlier pending Event for op.
+func (ws *watchState) delay(op dbus.ObjectPath, e Event) {
+	now := time.Now()
+
+	pe, ok := ws.pending[op]
+	if ok {
+		// Keep the properties from before the first change.
+		pe.e.New = e.New
+		if ws.opts.Debounce == 0 {
+			// Coalescing only: the timer set by the first change stands.
+			return
+		}
+
+		ws.stopTimer(pe)
+		pe.gen++
+	} else {
+		pe = &pendingEvent{e: e, first: now}
+		ws.pending[op] = pe
+	}
+
+	d := ws.opts.Debounce
+	if ws.opts.Coalesce > 0 {
+		if limit := pe.first.Add(ws.opts.Coalesce).Sub(now); d == 0 || limit < d {
+			d = limit
+		}
+	}
+
+	// Track the timer's callback so that Close waits for it. stopTimer
+	// accounts for callbacks which never run.
+	f := firedEvent{op: op, gen: pe.gen}
+	ws.wg.Add(1)
+	pe.timer = time.AfterFunc(d, func() {
+		defer ws.wg.Done()
+
+		select {
+		case <-ws.ctx.Done():
+		case ws.fired <- f:
+		}
+	})
+}
+
+// stopTimer stops the timer for pe, accounting for its callback if the timer
+// had not yet fired.
+func (ws *watchState) stopTimer(pe *pendingEvent) {
+	if pe.timer.Stop() {
+		ws.wg.Done()
+	}
+}
+
+// propertiesEqual reports whether a and b contain the same properties,
+// ignoring their raw D-Bus values.
+func propertiesEqual(a, b Properties) bool {
+	switch p := a.(type) {
+	case ManagerProperties:
+		p.Raw = nil
+		a = p
+	case LinkProperties:
+		p.Raw = nil
+		a = p
+	}
+	switch p := b.(type) {
+	case ManagerProperties:
+		p.Raw = nil
+		b = p
+	case LinkProperties:
+		p.Raw = nil
+		b = p
+	}
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,39 @@
+//go:build go1.23
+
+package networkd
+
+import (
+	"context"
+	"iter"
+)
+
+// Events returns an iterator over the Events reported by a Watcher configured
+// by opts for the lifetime of the iteration. The Watcher is closed when the
+// loop exits or ctx is canceled. Errors which occur while creating or running
+// the Watcher, or from ctx, are yielded once, after which iteration stops.
+func (c *Client) Events(ctx context.Context, opts *WatchOptions) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		w, err := c.Watch(ctx, opts)
+		if err != nil {
+			yield(Event{}, err)
+			return
+		}
+		defer w.Close()
+
+		for e := range w.Events() {
+			if !yield(e, nil) {
+				return
+			}
+		}
+
+		// The Watcher only stops on its own due to an error or when ctx is
+		// canceled.
+		if err := w.Err(); err != nil {
+			yield(Event{}, err)
+			return
+		}
+		if err := ctx.Err(); err != nil {
+			yield(Event{}, err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+//go:build go1.23
+
+package networkd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientEvents(t *testing.T) {
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	// Wait for the name owner, Manager, and links to be watched before sending
+	// a signal.
+	go func() {
+		for active.Load() != 3 {
+			time.Sleep(time.Millisecond)
+		}
+
+		signals <- testPropertiesChanged(objectPath(), "Manager", "OnlineState", "partial")
+	}()
+
+	var kinds []EventKind
+	for e, err := range c.Events(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("failed to iterate events: %v", err)
+		}
+
+		kinds = append(kinds, e.Kind)
+		break
+	}
+
+	if diff := cmp.Diff([]EventKind{EventManagerChanged}, kinds); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	// Leaving the loop closes the Watcher.
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var err error
+	for _, err = range c.Events(ctx, nil) {
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, but got: %v", err)
+	}
+}
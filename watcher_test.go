@@ -0,0 +1,567 @@
+package networkd
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestClientWatch(t *testing.T) {
+	eth1 := Link{Index: 3, Name: "eth1", ObjectPath: objectPath("link", "_33")}
+
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+		eth1.ObjectPath:         testLinkProperties(),
+	})
+	setLinks := testDynamicLinks(t, c)
+
+	w, err := c.Watch(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+
+	manager, err := parseManagerProperties(testManagerProperties())
+	if err != nil {
+		t.Fatalf("failed to parse manager properties: %v", err)
+	}
+	link, err := parseLinkProperties(testLinkProperties())
+	if err != nil {
+		t.Fatalf("failed to parse link properties: %v", err)
+	}
+
+	partial := manager
+	partial.OnlineState = OnlinePartial
+
+	degraded := link
+	degraded.OperationalState = OperationalDegraded
+
+	// Send each signal only after the previous event is received, since
+	// events from separate objects may otherwise be reordered.
+	tests := []struct {
+		name   string
+		before func()
+		signal *dbus.Signal
+		want   Event
+	}{
+		{
+			name:   "manager changed",
+			signal: testPropertiesChanged(objectPath(), "Manager", "OnlineState", "partial"),
+			want: Event{
+				Kind: EventManagerChanged,
+				Old:  manager,
+				New:  partial,
+			},
+		},
+		{
+			name:   "link changed",
+			signal: testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded"),
+			want: Event{
+				Kind: EventLinkChanged,
+				Link: testLink,
+				Old:  link,
+				New:  degraded,
+			},
+		},
+		{
+			name:   "link added",
+			before: func() { setLinks(testLinks[0], testLink, eth1) },
+			signal: testPropertiesChanged(eth1.ObjectPath, "Link", "AdministrativeState", "configured"),
+			want: Event{
+				Kind: EventLinkAdded,
+				Link: eth1,
+				New:  link,
+			},
+		},
+		{
+			name:   "link removed",
+			before: func() { setLinks(testLinks[0], eth1) },
+			signal: testPropertiesChanged(testLink.ObjectPath, "Link", "AdministrativeState", "linger"),
+			want: Event{
+				Kind: EventLinkRemoved,
+				Link: testLink,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.before != nil {
+			tt.before()
+		}
+		signals <- tt.signal
+
+		opts := []cmp.Option{
+			cmpopts.IgnoreFields(ManagerProperties{}, "Raw"),
+			cmpopts.IgnoreFields(LinkProperties{}, "Raw"),
+		}
+
+		e := <-w.Events()
+		if tt.want.Kind == EventLinkRemoved {
+			// The linger state may or may not be reported as a change
+			// before the link is removed, so only check that the link's
+			// last known properties are reported.
+			if e.Kind == EventLinkChanged {
+				e = <-w.Events()
+			}
+			if _, ok := e.Old.(LinkProperties); !ok {
+				t.Fatalf("unexpected old properties for removed link: %#v", e.Old)
+			}
+
+			opts = append(opts, cmpopts.IgnoreFields(Event{}, "Old"))
+		}
+
+		if diff := cmp.Diff(tt.want, e, opts...); diff != "" {
+			t.Fatalf("%s: unexpected event (-want +got):\n%s", tt.name, diff)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close watcher: %v", err)
+	}
+	for range w.Events() {
+	}
+
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestClientWatchDebounce(t *testing.T) {
+	c, signals, _ := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	const debounce = 20 * time.Millisecond
+	w, err := c.Watch(context.Background(), &WatchOptions{Debounce: debounce})
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Close()
+
+	// A link which flaps and settles back to its original state is not
+	// reported.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded")
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "routable")
+	time.Sleep(5 * debounce)
+
+	// A burst of changes which settles on a new state is reported once.
+	signals <- testPropertiesChanged(objectPath(), "Manager", "OperationalState", "degraded")
+	signals <- testPropertiesChanged(objectPath(), "Manager", "OnlineState", "partial")
+
+	manager, err := parseManagerProperties(testManagerProperties())
+	if err != nil {
+		t.Fatalf("failed to parse manager properties: %v", err)
+	}
+
+	degraded := manager
+	degraded.OperationalState = OperationalDegraded
+	degraded.OnlineState = OnlinePartial
+
+	want := Event{
+		Kind: EventManagerChanged,
+		Old:  manager,
+		New:  degraded,
+	}
+
+	if diff := cmp.Diff(want, <-w.Events(), cmpopts.IgnoreFields(ManagerProperties{}, "Raw")); diff != "" {
+		t.Fatalf("unexpected event (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientWatchFilters(t *testing.T) {
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	if _, err := c.Watch(context.Background(), &WatchOptions{Links: []string{"["}}); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	w, err := c.Watch(context.Background(), &WatchOptions{
+		Links:               []string{"eth*"},
+		MinOperationalState: OperationalRoutable,
+	})
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Close()
+
+	// A single match covers all links, regardless of the filters.
+	if diff := cmp.Diff(int32(3), active.Load()); diff != "" {
+		t.Fatalf("unexpected number of watches (-want +got):\n%s", diff)
+	}
+
+	// Changes which never reach the minimum state are not reported.
+	for _, state := range []string{"degraded", "carrier", "routable"} {
+		signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", state)
+	}
+
+	var got [][2]OperationalState
+	for i := 0; i < 2; i++ {
+		e := <-w.Events()
+		got = append(got, [2]OperationalState{
+			e.Old.(LinkProperties).OperationalState,
+			e.New.(LinkProperties).OperationalState,
+		})
+	}
+
+	want := [][2]OperationalState{
+		{OperationalRoutable, OperationalDegraded},
+		{OperationalCarrier, OperationalRoutable},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected state transitions (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientWatchResync(t *testing.T) {
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	w, err := c.Watch(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+
+	// Ownership changes for other names are ignored, and networkd stopping
+	// produces no events until it starts again.
+	signals <- testNameOwnerChanged("org.freedesktop.resolve1", ":1.10")
+	signals <- testNameOwnerChanged(baseService, "")
+	signals <- testNameOwnerChanged(baseService, ":1.42")
+
+	manager, err := parseManagerProperties(testManagerProperties())
+	if err != nil {
+		t.Fatalf("failed to parse manager properties: %v", err)
+	}
+
+	want := Event{Kind: EventResync, New: manager}
+	if diff := cmp.Diff(want, <-w.Events(), cmpopts.IgnoreFields(ManagerProperties{}, "Raw")); diff != "" {
+		t.Fatalf("unexpected resync event (-want +got):\n%s", diff)
+	}
+
+	// Links are watched again after the resync.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded")
+
+	e := <-w.Events()
+	if diff := cmp.Diff(EventLinkChanged, e.Kind); diff != "" {
+		t.Fatalf("unexpected event kind (-want +got):\n%s", diff)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close watcher: %v", err)
+	}
+	if err := w.Err(); err != nil {
+		t.Fatalf("unexpected watcher error: %v", err)
+	}
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestClientWatchSnapshot(t *testing.T) {
+	c, signals, _ := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	w, err := c.Watch(context.Background(), &WatchOptions{
+		Links:    []string{"eth*"},
+		Snapshot: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Close()
+
+	manager, err := parseManagerProperties(testManagerProperties())
+	if err != nil {
+		t.Fatalf("failed to parse manager properties: %v", err)
+	}
+	link, err := parseLinkProperties(testLinkProperties())
+	if err != nil {
+		t.Fatalf("failed to parse link properties: %v", err)
+	}
+
+	snapshot := []Event{
+		{Kind: EventManagerChanged, New: manager},
+		{Kind: EventLinkAdded, Link: testLink, New: link},
+		{Kind: EventSnapshotDone},
+	}
+
+	// The snapshot is reported initially, and again after networkd restarts.
+	want := append(append([]Event{}, snapshot...), Event{Kind: EventResync, New: manager})
+	want = append(want, snapshot...)
+
+	var got []Event
+	for i := 0; i < len(want); i++ {
+		if i == len(snapshot) {
+			signals <- testNameOwnerChanged(baseService, ":1.42")
+		}
+
+		got = append(got, <-w.Events())
+	}
+
+	opts := []cmp.Option{
+		cmpopts.IgnoreFields(ManagerProperties{}, "Raw"),
+		cmpopts.IgnoreFields(LinkProperties{}, "Raw"),
+	}
+
+	if diff := cmp.Diff(want, got, opts...); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientWatchFunc(t *testing.T) {
+	c, signals, active := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string][]EventKind)
+	)
+
+	opts := &WatchOptions{Snapshot: true, Workers: 2}
+	err := c.WatchFunc(ctx, opts, func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		seen[e.Link.Name] = append(seen[e.Link.Name], e.Kind)
+
+		switch {
+		case e.Kind == EventSnapshotDone:
+			signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded")
+		case e.Kind == EventLinkChanged:
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, but got: %v", err)
+	}
+
+	// Events for each link are handled in order.
+	want := map[string][]EventKind{
+		"":     {EventManagerChanged, EventSnapshotDone},
+		"lo":   {EventLinkAdded},
+		"eth0": {EventLinkAdded, EventLinkChanged},
+	}
+
+	if diff := cmp.Diff(want, seen); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+	if n := active.Load(); n != 0 {
+		t.Fatalf("%d watches were not canceled", n)
+	}
+}
+
+func TestClientWatchCarrier(t *testing.T) {
+	c, signals, _ := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	w, err := c.Watch(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Close()
+
+	// A degraded carrier is still a carrier.
+	for _, state := range []string{"degraded-carrier", "no-carrier", "carrier"} {
+		signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "CarrierState", state)
+	}
+
+	var got []EventKind
+	for i := 0; i < 5; i++ {
+		got = append(got, (<-w.Events()).Kind)
+	}
+
+	want := []EventKind{
+		EventLinkChanged,
+		EventLinkChanged, EventCarrierLost,
+		EventLinkChanged, EventCarrierGained,
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientWatchRoutable(t *testing.T) {
+	c, signals, _ := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	describe := testFixture(t, "link-eth0.json")
+	manager := c.call
+	c.call = func(ctx context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
+		if method != interfacePath("Link", "Describe") {
+			return manager(ctx, service, method, op, out, args...)
+		}
+
+		if diff := cmp.Diff(testLink.ObjectPath, op); diff != "" {
+			t.Errorf("unexpected object path (-want +got):\n%s", diff)
+		}
+
+		*out.(*string) = describe
+		return nil
+	}
+
+	w, err := c.Watch(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Close()
+
+	// IPv4 is already routable, so only IPv6 is reported.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "IPv6AddressState", "routable")
+
+	if diff := cmp.Diff(EventLinkChanged, (<-w.Events()).Kind); diff != "" {
+		t.Fatalf("unexpected event kind (-want +got):\n%s", diff)
+	}
+
+	e := <-w.Events()
+	if diff := cmp.Diff(EventIPv6Routable, e.Kind); diff != "" {
+		t.Fatalf("unexpected event kind (-want +got):\n%s", diff)
+	}
+
+	want := []netip.Prefix{
+		netip.MustParsePrefix("2001:db8::5054:ff:fe12:3456/64"),
+		netip.MustParsePrefix("2001:db8:ffff::10/128"),
+	}
+
+	if diff := cmp.Diff(want, e.Addresses, cmp.Comparer(prefixEqual)); diff != "" {
+		t.Fatalf("unexpected addresses (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientWatchRoutableSlowDescribe(t *testing.T) {
+	c, signals, _ := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	// Describe blocks until released, as if networkd were hung.
+	var (
+		describe = testFixture(t, "link-eth0.json")
+		release  = make(chan struct{})
+		manager  = c.call
+	)
+	c.call = func(ctx context.Context, service, method string, op dbus.ObjectPath, out any, args ...any) error {
+		if method != interfacePath("Link", "Describe") {
+			return manager(ctx, service, method, op, out, args...)
+		}
+
+		<-release
+		*out.(*string) = describe
+		return nil
+	}
+
+	w, err := c.Watch(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Close()
+
+	// Events which follow the routable change are not blocked by the fetch of
+	// its addresses.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "IPv6AddressState", "routable")
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "CarrierState", "no-carrier")
+
+	var got []EventKind
+	for i := 0; i < 3; i++ {
+		got = append(got, (<-w.Events()).Kind)
+	}
+
+	close(release)
+	e := <-w.Events()
+	got = append(got, e.Kind)
+
+	want := []EventKind{
+		EventLinkChanged,
+		EventLinkChanged, EventCarrierLost,
+		EventIPv6Routable,
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+	if len(e.Addresses) == 0 {
+		t.Fatal("expected routable addresses, but none were reported")
+	}
+}
+
+func TestClientWatchCoalesce(t *testing.T) {
+	c, signals, _ := testWatchClient(t, map[dbus.ObjectPath]map[string]dbus.Variant{
+		objectPath():            testManagerProperties(),
+		testLinks[0].ObjectPath: testLinkProperties(),
+		testLink.ObjectPath:     testLinkProperties(),
+	})
+	_ = testDynamicLinks(t, c)
+
+	const coalesce = 20 * time.Millisecond
+	w, err := c.Watch(context.Background(), &WatchOptions{Coalesce: coalesce})
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Close()
+
+	// A burst of changes is reported as one event, followed later by a
+	// separate change.
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "degraded")
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OperationalState", "carrier")
+	time.Sleep(5 * coalesce)
+	signals <- testPropertiesChanged(testLink.ObjectPath, "Link", "OnlineState", "partial")
+
+	var got [][2]OperationalState
+	for i := 0; i < 2; i++ {
+		e := <-w.Events()
+		got = append(got, [2]OperationalState{
+			e.Old.(LinkProperties).OperationalState,
+			e.New.(LinkProperties).OperationalState,
+		})
+	}
+
+	want := [][2]OperationalState{
+		{OperationalRoutable, OperationalCarrier},
+		{OperationalCarrier, OperationalCarrier},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected state transitions (-want +got):\n%s", diff)
+	}
+}